@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"bluebell_backend/dao/mysql"
+	"bluebell_backend/logic"
+	"bluebell_backend/models"
+	"database/sql"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SetUserRoleHandler 管理员设置用户角色 PUT /admin/user/:id/role
+func SetUserRoleHandler(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	p := new(models.ParamSetRole)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("SetUserRole with invalid param", zap.Error(err))
+		responseBindError(c, err)
+		return
+	}
+	if err := logic.SetUserRole(userID, p.Role); err != nil {
+		if err == sql.ErrNoRows {
+			ResponseError(c, CodeUserNotExist)
+			return
+		}
+		zap.L().Error("logic.SetUserRole failed", zap.Uint64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// CreateCommunityHandler 管理员创建社区 POST /admin/community
+func CreateCommunityHandler(c *gin.Context) {
+	p := new(models.ParamCreateCommunity)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("CreateCommunity with invalid param", zap.Error(err))
+		responseBindError(c, err)
+		return
+	}
+	data, err := logic.CreateCommunity(p)
+	if err != nil {
+		if err == mysql.ErrorCommunityExist {
+			ResponseError(c, CodeCommunityExist)
+			return
+		}
+		zap.L().Error("logic.CreateCommunity failed", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// AddModeratorHandler 管理员指派社区版主 POST /admin/community/:id/moderators
+func AddModeratorHandler(c *gin.Context) {
+	communityID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	p := new(models.ParamModerator)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("AddModerator with invalid param", zap.Error(err))
+		responseBindError(c, err)
+		return
+	}
+	if err := logic.AddCommunityModerator(communityID, p); err != nil {
+		switch err {
+		case logic.ErrorCommunityNotExist:
+			ResponseError(c, CodeCommunityNotExist)
+		case sql.ErrNoRows:
+			ResponseError(c, CodeUserNotExist)
+		default:
+			zap.L().Error("logic.AddCommunityModerator failed", zap.Uint64("community_id", communityID), zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+		}
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// RemoveModeratorHandler 管理员撤销社区版主 DELETE /admin/community/:id/moderators/:user_id
+func RemoveModeratorHandler(c *gin.Context) {
+	communityID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	if err := logic.RemoveCommunityModerator(communityID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			ResponseError(c, CodeUserNotExist)
+			return
+		}
+		zap.L().Error("logic.RemoveCommunityModerator failed", zap.Uint64("community_id", communityID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// ModeratorRemovePostHandler 版主删除社区中的帖子 DELETE /community/:id/post/:post_id
+func ModeratorRemovePostHandler(c *gin.Context) {
+	communityID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	postID, err := strconv.ParseUint(c.Param("post_id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	if err := logic.RemovePost(communityID, postID); err != nil {
+		if err == logic.ErrorPostNotExist {
+			ResponseError(c, CodePostNotExist)
+			return
+		}
+		zap.L().Error("logic.RemovePost failed", zap.Uint64("post_id", postID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// SetPostFlagsHandler 版主置顶、锁定帖子或设为精华 PUT /community/:id/post/:post_id/flags
+func SetPostFlagsHandler(c *gin.Context) {
+	communityID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	postID, err := strconv.ParseUint(c.Param("post_id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	p := new(models.ParamPostFlags)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("SetPostFlags with invalid param", zap.Error(err))
+		responseBindError(c, err)
+		return
+	}
+	if err := logic.SetPostFlags(communityID, postID, p); err != nil {
+		switch err {
+		case logic.ErrorPostNotExist:
+			ResponseError(c, CodePostNotExist)
+		case logic.ErrorTooManyPinned:
+			ResponseError(c, CodeTooManyPinned)
+		default:
+			zap.L().Error("logic.SetPostFlags failed", zap.Uint64("post_id", postID), zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+		}
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// ModeratorRemoveCommentHandler 版主删除社区中的评论 DELETE /community/:id/comment/:comment_id
+func ModeratorRemoveCommentHandler(c *gin.Context) {
+	communityID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	commentID, err := strconv.ParseUint(c.Param("comment_id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	if err := logic.RemoveComment(communityID, commentID); err != nil {
+		if err == logic.ErrorCommentNotExist {
+			ResponseError(c, CodeCommentNotExist)
+			return
+		}
+		zap.L().Error("logic.RemoveComment failed", zap.Uint64("comment_id", commentID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"bluebell_backend/logic"
+	"bluebell_backend/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AddBookmarkHandler 收藏帖子，可以指定收藏夹 POST /post/:id/bookmark
+func AddBookmarkHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	p := new(models.ParamBookmark)
+	// 请求体可以为空，此时不放入任何收藏夹
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(p); err != nil {
+			zap.L().Error("AddBookmark with invalid param", zap.Error(err))
+			responseBindError(c, err)
+			return
+		}
+	}
+	if err := logic.AddBookmark(userID, postID, p); err != nil {
+		switch err {
+		case logic.ErrorPostNotExist:
+			ResponseError(c, CodePostNotExist)
+		case logic.ErrorCollectionNotExist:
+			ResponseError(c, CodeCollectionNotExist)
+		default:
+			zap.L().Error("logic.AddBookmark failed", zap.Uint64("post_id", postID), zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+		}
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// RemoveBookmarkHandler 取消收藏帖子 DELETE /post/:id/bookmark
+func RemoveBookmarkHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	if err := logic.RemoveBookmark(userID, postID); err != nil {
+		zap.L().Error("logic.RemoveBookmark failed", zap.Uint64("post_id", postID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// BookmarkListHandler 按收藏时间降序分页查询我收藏的帖子 GET /me/bookmarks?page=1&size=10&collection_id=
+func BookmarkListHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	p := &models.ParamBookmarkList{
+		Page: 1,
+		Size: 10,
+	}
+	if err := c.ShouldBindQuery(p); err != nil || p.Page < 1 || p.Size < 1 {
+		zap.L().Error("BookmarkListHandler with invalid params", zap.Error(err))
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	data, err := logic.GetBookmarkList(userID, p)
+	if err != nil {
+		if err == logic.ErrorCollectionNotExist {
+			ResponseError(c, CodeCollectionNotExist)
+			return
+		}
+		zap.L().Error("logic.GetBookmarkList failed", zap.Uint64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// CollectionListHandler 查询我的收藏夹 GET /me/collections
+func CollectionListHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	data, err := logic.GetCollections(userID)
+	if err != nil {
+		zap.L().Error("logic.GetCollections failed", zap.Uint64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// CreateCollectionHandler 创建收藏夹 POST /me/collections
+func CreateCollectionHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	p := new(models.ParamCollection)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("CreateCollection with invalid param", zap.Error(err))
+		responseBindError(c, err)
+		return
+	}
+	data, err := logic.CreateCollection(userID, p)
+	if err != nil {
+		if err == logic.ErrorCollectionExist {
+			ResponseError(c, CodeCollectionExist)
+			return
+		}
+		zap.L().Error("logic.CreateCollection failed", zap.Uint64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// DeleteCollectionHandler 删除收藏夹，其中的帖子仍保留在收藏中 DELETE /me/collections/:id
+func DeleteCollectionHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	collectionID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	if err := logic.DeleteCollection(userID, collectionID); err != nil {
+		if err == logic.ErrorCollectionNotExist {
+			ResponseError(c, CodeCollectionNotExist)
+			return
+		}
+		zap.L().Error("logic.DeleteCollection failed", zap.Uint64("collection_id", collectionID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
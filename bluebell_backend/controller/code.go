@@ -7,17 +7,52 @@ package controller
 type MyCode int64
 
 const (
-	CodeSuccess           MyCode = 1000
-	CodeInvalidParams     MyCode = 1001
-	CodeUserExist         MyCode = 1002
-	CodeUserNotExist      MyCode = 1003
-	CodeInvalidPassword   MyCode = 1004
-	CodeServerBusy        MyCode = 1005
-	CodeInvalidToken      MyCode = 1006
-	CodeInvalidAuthFormat MyCode = 1007
-	CodeNotLogin          MyCode = 1008
-	ErrVoteRepeated       MyCode = 1009
-	ErrorVoteTimeExpire   MyCode = 1010
+	CodeSuccess               MyCode = 1000
+	CodeInvalidParams         MyCode = 1001
+	CodeUserExist             MyCode = 1002
+	CodeUserNotExist          MyCode = 1003
+	CodeInvalidPassword       MyCode = 1004
+	CodeServerBusy            MyCode = 1005
+	CodeInvalidToken          MyCode = 1006
+	CodeInvalidAuthFormat     MyCode = 1007
+	CodeNotLogin              MyCode = 1008
+	ErrVoteRepeated           MyCode = 1009
+	ErrorVoteTimeExpire       MyCode = 1010
+	CodeLoginElsewhere        MyCode = 1011
+	CodeTokenRevoked          MyCode = 1012
+	CodeNoPermission          MyCode = 1013
+	CodeRefreshTokenReused    MyCode = 1014
+	CodeEmailNotVerified      MyCode = 1015
+	CodeInvalidVerifyToken    MyCode = 1016
+	CodeEmailVerified         MyCode = 1017
+	CodeInvalidResetToken     MyCode = 1018
+	CodeCommunityExist        MyCode = 1019
+	CodeCommunityNotExist     MyCode = 1020
+	CodePostNotExist          MyCode = 1021
+	CodeCommentNotExist       MyCode = 1022
+	CodeTwoFactorEnabled      MyCode = 1023
+	CodeTwoFactorNotEnabled   MyCode = 1024
+	CodeInvalidTwoFactorCode  MyCode = 1025
+	CodeInvalidTwoFactorToken MyCode = 1026
+	CodeRevisionNotExist      MyCode = 1027
+	CodePostPublished         MyCode = 1028
+	CodeInvalidTag            MyCode = 1029
+	CodeTooManyTags           MyCode = 1030
+	CodeInvalidAttachment     MyCode = 1031
+	CodeFileTooLarge          MyCode = 1032
+	CodeFileTypeNotAllowed    MyCode = 1033
+	CodePostLocked            MyCode = 1034
+	CodeTooManyPinned         MyCode = 1035
+	CodeInvalidPoll           MyCode = 1036
+	CodePollNotExist          MyCode = 1037
+	CodePollClosed            MyCode = 1038
+	CodePollVoted             MyCode = 1039
+	CodeInvalidPollOption     MyCode = 1040
+	CodeCollectionNotExist    MyCode = 1041
+	CodeCollectionExist       MyCode = 1042
+	CodeEmptyComment          MyCode = 1043
+	CodeParentCommentNotExist MyCode = 1044
+	CodeNotificationNotExist  MyCode = 1045
 )
 
 var msgFlags = map[MyCode]string{
@@ -28,11 +63,46 @@ var msgFlags = map[MyCode]string{
 	CodeInvalidPassword: "用户名或密码错误",
 	CodeServerBusy:      "服务繁忙",
 
-	CodeInvalidToken:      "无效的Token",
-	CodeInvalidAuthFormat: "认证格式有误",
-	CodeNotLogin:          "未登录",
-	ErrVoteRepeated:       "请勿重复投票",
-	ErrorVoteTimeExpire:   "投票时间已过",
+	CodeInvalidToken:          "无效的Token",
+	CodeInvalidAuthFormat:     "认证格式有误",
+	CodeNotLogin:              "未登录",
+	ErrVoteRepeated:           "请勿重复投票",
+	ErrorVoteTimeExpire:       "投票时间已过",
+	CodeLoginElsewhere:        "账号已在其他设备登录",
+	CodeTokenRevoked:          "Token已失效，请重新登录",
+	CodeNoPermission:          "没有权限",
+	CodeRefreshTokenReused:    "登录状态异常，请重新登录",
+	CodeEmailNotVerified:      "请先验证邮箱",
+	CodeInvalidVerifyToken:    "验证链接无效或已过期",
+	CodeEmailVerified:         "邮箱已验证",
+	CodeInvalidResetToken:     "重置链接无效或已过期",
+	CodeCommunityExist:        "社区已存在",
+	CodeCommunityNotExist:     "社区不存在",
+	CodePostNotExist:          "帖子不存在",
+	CodeCommentNotExist:       "评论不存在",
+	CodeTwoFactorEnabled:      "已开启两步验证",
+	CodeTwoFactorNotEnabled:   "未开启两步验证",
+	CodeInvalidTwoFactorCode:  "验证码错误",
+	CodeInvalidTwoFactorToken: "登录验证已过期，请重新登录",
+	CodeRevisionNotExist:      "帖子版本不存在",
+	CodePostPublished:         "帖子已发布",
+	CodeInvalidTag:            "标签只能包含字母、数字、-和_，且不超过20个字符",
+	CodeTooManyTags:           "每篇帖子最多5个标签",
+	CodeInvalidAttachment:     "附件不存在或已被其他帖子使用",
+	CodeFileTooLarge:          "文件过大",
+	CodeFileTypeNotAllowed:    "不支持的文件类型",
+	CodePostLocked:            "帖子已锁定，不能评论和投票",
+	CodeTooManyPinned:         "置顶帖子数量已达上限",
+	CodeInvalidPoll:           "投票需要2~10个选项，截止时间需晚于发布时间",
+	CodePollNotExist:          "投票不存在",
+	CodePollClosed:            "投票已截止",
+	CodePollVoted:             "已参与过该投票",
+	CodeInvalidPollOption:     "无效的投票选项",
+	CodeCollectionNotExist:    "收藏夹不存在",
+	CodeCollectionExist:       "收藏夹已存在",
+	CodeEmptyComment:          "评论内容不能为空",
+	CodeParentCommentNotExist: "回复的评论不存在或不属于该帖子",
+	CodeNotificationNotExist:  "通知不存在",
 }
 
 func (c MyCode) Msg() string {
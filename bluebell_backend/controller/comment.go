@@ -2,44 +2,115 @@ package controller
 
 import (
 	"bluebell_backend/dao/mysql"
+	"bluebell_backend/dao/redis"
+	"bluebell_backend/logic"
 	"bluebell_backend/models"
-	"bluebell_backend/pkg/snowflake"
-	"fmt"
+	"bluebell_backend/pkg/markdown"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+// commentErrorCode 将评论相关的错误转换为响应状态码
+func commentErrorCode(err error) (MyCode, bool) {
+	switch err {
+	case logic.ErrorPostNotExist:
+		return CodePostNotExist, true
+	case logic.ErrorPostLocked:
+		return CodePostLocked, true
+	case logic.ErrorCommentNotExist:
+		return CodeCommentNotExist, true
+	case logic.ErrorParentCommentNotExist:
+		return CodeParentCommentNotExist, true
+	case logic.ErrorEmptyComment:
+		return CodeEmptyComment, true
+	case logic.ErrorNoPermission:
+		return CodeNoPermission, true
+	}
+	return 0, false
+}
+
 // CommentHandler 创建评论
 func CommentHandler(c *gin.Context) {
+	// 获取作者ID，从请求上下文的userID获取
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		zap.L().Error("GetCurrentUserID() failed", zap.Error(err))
+		ResponseError(c, CodeNotLogin)
+		return
+	}
 	// 1.接收参数
-	var comment models.Comment
-	if err := c.BindJSON(&comment); err != nil {
-		fmt.Println(err)
-		ResponseError(c, CodeInvalidParams)
+	p := new(models.ParamComment)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("CreateComment with invalid param", zap.Error(err))
+		responseBindError(c, err)
 		return
 	}
 
-	// 雪花算法生成评论ID
-	commentID, err := snowflake.GetID()
+	// 2.校验并在数据库中插入评论
+	comment, err := logic.CreateComment(userID, p)
 	if err != nil {
-		zap.L().Error("snowflake.GetID() failed", zap.Error(err))
+		if code, ok := commentErrorCode(err); ok {
+			ResponseError(c, code)
+			return
+		}
+		zap.L().Error("logic.CreateComment failed", zap.Uint64("post_id", p.PostID), zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
-	// 获取作者ID，从请求上下文的userID获取
-	userID, err := getCurrentUserID(c)
+	ResponseSuccess(c, comment)
+}
+
+// UpdateCommentHandler 编辑评论，评论作者、版主和管理员可以编辑 PUT /comment/:id
+func UpdateCommentHandler(c *gin.Context) {
+	mc, err := getCurrentClaims(c)
 	if err != nil {
-		zap.L().Error("GetCurrentUserID() failed", zap.Error(err))
 		ResponseError(c, CodeNotLogin)
 		return
 	}
-	comment.CommentID = commentID
-	comment.AuthorID = userID
+	commentID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	p := new(models.ParamUpdateComment)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("UpdateComment with invalid param", zap.Error(err))
+		responseBindError(c, err)
+		return
+	}
+	comment, err := logic.UpdateComment(mc, commentID, p)
+	if err != nil {
+		if code, ok := commentErrorCode(err); ok {
+			ResponseError(c, code)
+			return
+		}
+		zap.L().Error("logic.UpdateComment failed", zap.Uint64("comment_id", commentID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, comment)
+}
 
-	// 2.在数据库中插入评论
-	if err := mysql.CreateComment(&comment); err != nil {
-		zap.L().Error("mysql.CreateComment(&comment) failed", zap.Error(err))
+// DeleteCommentHandler 删除评论，评论作者、版主和管理员可以删除 DELETE /comment/:id
+func DeleteCommentHandler(c *gin.Context) {
+	mc, err := getCurrentClaims(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	commentID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	if err := logic.DeleteComment(mc, commentID); err != nil {
+		if code, ok := commentErrorCode(err); ok {
+			ResponseError(c, code)
+			return
+		}
+		zap.L().Error("logic.DeleteComment failed", zap.Uint64("comment_id", commentID), zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
@@ -56,10 +127,79 @@ func CommentListHandler(c *gin.Context) {
 		return
 	}
 	// 2.从数据库中获取每条评论的详细信息
-	posts, err := mysql.GetCommentListByIDs(ids)
+	comments, err := mysql.GetCommentListByIDs(ids)
 	if err != nil {
 		ResponseError(c, CodeServerBusy)
 		return
 	}
-	ResponseSuccess(c, posts)
+	for _, comment := range comments {
+		comment.ContentHTML = markdown.Render(comment.Content)
+	}
+	ResponseSuccess(c, comments)
+}
+
+// CommentTreeHandler 分页查询帖子的评论树，登录时返回当前用户的投票 GET /post/:id/comments?page=1&size=10&order=best&depth=4&parent_id=
+func CommentTreeHandler(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	p := &models.ParamCommentTree{
+		Order: models.OrderTime,
+		Page:  1,
+		Size:  10,
+	}
+	if err := c.ShouldBindQuery(p); err != nil || p.Page < 1 || p.Size < 1 || p.Size > 100 ||
+		(p.Order != models.OrderTime && p.Order != models.OrderScore && p.Order != models.OrderBest) {
+		zap.L().Error("CommentTreeHandler with invalid params", zap.Error(err))
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	userID, _ := getCurrentUserID(c) // 未登录时为0
+	data, err := logic.GetCommentTree(postID, userID, p)
+	if err != nil {
+		if code, ok := commentErrorCode(err); ok {
+			ResponseError(c, code)
+			return
+		}
+		zap.L().Error("logic.GetCommentTree failed", zap.Uint64("post_id", postID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// CommentVoteHandler 为评论投票 POST /comment/:id/vote
+func CommentVoteHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	commentID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	p := new(models.ParamCommentVote)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("CommentVote with invalid param", zap.Error(err))
+		responseBindError(c, err)
+		return
+	}
+	if err := logic.VoteComment(userID, commentID, p); err != nil {
+		if code, ok := commentErrorCode(err); ok {
+			ResponseError(c, code)
+			return
+		}
+		if err == redis.ErrVoteRepeated {
+			ResponseError(c, ErrVoteRepeated)
+			return
+		}
+		zap.L().Error("logic.VoteComment failed", zap.Uint64("comment_id", commentID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
 }
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"bluebell_backend/logic"
+	"bluebell_backend/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// NotificationListHandler 按时间降序分页查询我的通知 GET /notifications?page=1&size=10&unread=true
+func NotificationListHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	p := &models.ParamNotificationList{
+		Page: 1,
+		Size: 10,
+	}
+	if err := c.ShouldBindQuery(p); err != nil || p.Page < 1 || p.Size < 1 {
+		zap.L().Error("NotificationListHandler with invalid params", zap.Error(err))
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	data, err := logic.GetNotificationList(userID, p)
+	if err != nil {
+		zap.L().Error("logic.GetNotificationList failed", zap.Uint64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// MarkNotificationReadHandler 将一条通知标记为已读 POST /notifications/:id/read
+func MarkNotificationReadHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	notificationID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	if err := logic.MarkNotificationRead(userID, notificationID); err != nil {
+		if err == logic.ErrorNotificationNotExist {
+			ResponseError(c, CodeNotificationNotExist)
+			return
+		}
+		zap.L().Error("logic.MarkNotificationRead failed", zap.Uint64("notification_id", notificationID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// MarkAllNotificationsReadHandler 将我的所有通知标记为已读 POST /notifications/read_all
+func MarkAllNotificationsReadHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	if err := logic.MarkAllNotificationsRead(userID); err != nil {
+		zap.L().Error("logic.MarkAllNotificationsRead failed", zap.Uint64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"bluebell_backend/logic"
+	"bluebell_backend/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// pollErrorCode 将投票相关的错误转换为响应状态码
+func pollErrorCode(err error) (MyCode, bool) {
+	switch err {
+	case logic.ErrorInvalidPoll:
+		return CodeInvalidPoll, true
+	case logic.ErrorPollNotExist:
+		return CodePollNotExist, true
+	case logic.ErrorPollClosed:
+		return CodePollClosed, true
+	case logic.ErrorPollVoted:
+		return CodePollVoted, true
+	case logic.ErrorInvalidPollOption:
+		return CodeInvalidPollOption, true
+	}
+	return 0, false
+}
+
+// VotePollHandler 参与帖子的投票 POST /post/:id/poll/vote
+func VotePollHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	p := new(models.ParamPollVote)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("VotePoll with invalid param", zap.Error(err))
+		responseBindError(c, err)
+		return
+	}
+	data, err := logic.VotePoll(userID, postID, p)
+	if err != nil {
+		if code, ok := pollErrorCode(err); ok {
+			ResponseError(c, code)
+			return
+		}
+		switch err {
+		case logic.ErrorPostNotExist:
+			ResponseError(c, CodePostNotExist)
+		case logic.ErrorPostLocked:
+			ResponseError(c, CodePostLocked)
+		default:
+			zap.L().Error("logic.VotePoll failed", zap.Uint64("post_id", postID), zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+		}
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// ClosePollHandler 提前截止帖子的投票，只有作者、社区版主和管理员可以截止 POST /post/:id/poll/close
+func ClosePollHandler(c *gin.Context) {
+	mc, err := getCurrentClaims(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	if err := logic.ClosePoll(mc, postID); err != nil {
+		if code, ok := pollErrorCode(err); ok {
+			ResponseError(c, code)
+			return
+		}
+		switch err {
+		case logic.ErrorPostNotExist:
+			ResponseError(c, CodePostNotExist)
+		case logic.ErrorNoPermission:
+			ResponseError(c, CodeNoPermission)
+		default:
+			zap.L().Error("logic.ClosePoll failed", zap.Uint64("post_id", postID), zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+		}
+		return
+	}
+	ResponseSuccess(c, nil)
+}
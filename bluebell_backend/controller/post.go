@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"bluebell_backend/dao/mysql"
 	"bluebell_backend/logic"
 	"bluebell_backend/models"
 	"fmt"
@@ -34,11 +35,26 @@ func CreatePostHandler(c *gin.Context) {
 	// 3.业务处理逻辑——创建帖子
 	err = logic.CreatePost(&post)
 	if err != nil {
+		if code, ok := tagErrorCode(err); ok {
+			ResponseError(c, code)
+			return
+		}
+		if err == logic.ErrorInvalidAttachment {
+			ResponseError(c, CodeInvalidAttachment)
+			return
+		}
+		if err == logic.ErrorInvalidPoll {
+			ResponseError(c, CodeInvalidPoll)
+			return
+		}
 		zap.L().Error("logic.CreatePost failed", zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
-	ResponseSuccess(c, nil)
+	ResponseSuccess(c, gin.H{
+		"post_id": fmt.Sprintf("%d", post.PostID),
+		"status":  post.Status, // 1:已发布 2:草稿 3:定时发布
+	})
 }
 
 // PostListHandler 分页获取帖子列表
@@ -67,6 +83,10 @@ func PostList2Handler(c *gin.Context) {
 	// 2.业务代码逻辑——按时间/分数排序获取帖子列表
 	data, err := logic.GetPostListNew(p) // 更新：合二为一
 	if err != nil {
+		if code, ok := tagErrorCode(err); ok {
+			ResponseError(c, code)
+			return
+		}
 		ResponseError(c, CodeServerBusy)
 		return
 	}
@@ -81,14 +101,27 @@ func PostDetailHandler(c *gin.Context) {
 	if err != nil {
 		zap.L().Error("get post detail with invalid param", zap.Error(err))
 		ResponseError(c, CodeInvalidParams)
+		return
 	}
 
 	// 2.业务代码逻辑——查询帖子
 	post, err := logic.GetPostById(postId)
 	if err != nil {
 		zap.L().Error("logic.GetPost(postID) failed", zap.Error(err))
+		if err.Error() == mysql.ErrorInvalidID { // 帖子不存在或已删除
+			ResponseError(c, CodePostNotExist)
+			return
+		}
 		ResponseError(c, CodeServerBusy)
+		return
+	}
+	// 登录用户查询是否收藏了该帖子
+	userID, err := getCurrentUserID(c)
+	if err == nil {
+		post.Bookmarked = logic.IsBookmarked(userID, post.PostID)
 	}
+	// 记录浏览，未登录用户按IP识别
+	logic.RecordPostView(post.PostID, userID, c.ClientIP())
 
 	// 3.返回响应
 	ResponseSuccess(c, post)
@@ -138,3 +171,126 @@ func PostSearchHandler(c *gin.Context) {
 	}
 	ResponseSuccess(c, data)
 }
+
+// UpdatePostHandler 编辑帖子，只有作者、社区版主和管理员可以编辑
+func UpdatePostHandler(c *gin.Context) {
+	mc, err := getCurrentClaims(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	p := new(models.ParamUpdatePost)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("UpdatePost with invalid param", zap.Error(err))
+		responseBindError(c, err)
+		return
+	}
+	if err := logic.UpdatePost(mc, postID, p); err != nil {
+		switch err {
+		case logic.ErrorPostNotExist:
+			ResponseError(c, CodePostNotExist)
+		case logic.ErrorCommunityNotExist:
+			ResponseError(c, CodeCommunityNotExist)
+		case logic.ErrorNoPermission:
+			ResponseError(c, CodeNoPermission)
+		case logic.ErrorInvalidTag:
+			ResponseError(c, CodeInvalidTag)
+		case logic.ErrorTooManyTags:
+			ResponseError(c, CodeTooManyTags)
+		case logic.ErrorInvalidAttachment:
+			ResponseError(c, CodeInvalidAttachment)
+		default:
+			zap.L().Error("logic.UpdatePost failed", zap.Uint64("post_id", postID), zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+		}
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// DeletePostHandler 删除帖子，只有作者、社区版主和管理员可以删除
+func DeletePostHandler(c *gin.Context) {
+	mc, err := getCurrentClaims(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	if err := logic.DeletePost(mc, postID); err != nil {
+		switch err {
+		case logic.ErrorPostNotExist:
+			ResponseError(c, CodePostNotExist)
+		case logic.ErrorNoPermission:
+			ResponseError(c, CodeNoPermission)
+		default:
+			zap.L().Error("logic.DeletePost failed", zap.Uint64("post_id", postID), zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+		}
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// DraftListHandler 分页查询当前用户的草稿和定时发布的帖子
+func DraftListHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	page, size := getPageInfo(c)
+	data, err := logic.GetDraftList(userID, page, size)
+	if err != nil {
+		zap.L().Error("logic.GetDraftList failed", zap.Uint64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// PublishPostHandler 发布草稿，可以指定publish_at定时发布
+func PublishPostHandler(c *gin.Context) {
+	mc, err := getCurrentClaims(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	p := new(models.ParamPublishPost)
+	// 请求体可以为空，表示立即发布
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(p); err != nil {
+			zap.L().Error("PublishPost with invalid param", zap.Error(err))
+			responseBindError(c, err)
+			return
+		}
+	}
+	if err := logic.PublishDraft(mc, postID, p); err != nil {
+		switch err {
+		case logic.ErrorPostNotExist:
+			ResponseError(c, CodePostNotExist)
+		case logic.ErrorPostPublished:
+			ResponseError(c, CodePostPublished)
+		case logic.ErrorNoPermission:
+			ResponseError(c, CodeNoPermission)
+		default:
+			zap.L().Error("logic.PublishDraft failed", zap.Uint64("post_id", postID), zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+		}
+		return
+	}
+	ResponseSuccess(c, nil)
+}
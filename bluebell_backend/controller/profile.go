@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"bluebell_backend/logic"
+	"bluebell_backend/models"
+	"database/sql"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// UserProfileHandler 查询用户主页 GET /user/:id
+func UserProfileHandler(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	data, err := logic.GetUserProfile(userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ResponseError(c, CodeUserNotExist)
+			return
+		}
+		zap.L().Error("logic.GetUserProfile failed", zap.Uint64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// MyProfileHandler 查询当前用户的资料 GET /me
+func MyProfileHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	data, err := logic.GetMyProfile(userID)
+	if err != nil {
+		zap.L().Error("logic.GetMyProfile failed", zap.Uint64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// UpdateMyProfileHandler 修改当前用户的资料 PUT /me
+func UpdateMyProfileHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	p := new(models.ParamUpdateProfile)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("UpdateMyProfile with invalid param", zap.Error(err))
+		responseBindError(c, err)
+		return
+	}
+	data, err := logic.UpdateMyProfile(userID, p)
+	if err != nil {
+		zap.L().Error("logic.UpdateMyProfile failed", zap.Uint64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"bluebell_backend/pkg/jwt"
 	"errors"
 	"github.com/gin-gonic/gin"
 	"strconv"
@@ -8,6 +9,7 @@ import (
 
 const (
 	ContextUserIDKey = "userID"
+	ContextClaimsKey = "claims"
 )
 
 var (
@@ -30,6 +32,19 @@ func getCurrentUserID(c *gin.Context) (userID uint64, err error) {
 	return
 }
 
+// getCurrentClaims 获取当前请求Token的负载
+func getCurrentClaims(c *gin.Context) (*jwt.MyClaims, error) {
+	_claims, ok := c.Get(ContextClaimsKey)
+	if !ok {
+		return nil, ErrorUserNotLogin
+	}
+	mc, ok := _claims.(*jwt.MyClaims)
+	if !ok {
+		return nil, ErrorUserNotLogin
+	}
+	return mc, nil
+}
+
 // getPageInfo 分页参数
 func getPageInfo(c *gin.Context) (int64, int64) {
 	pageStr := c.Query("page")
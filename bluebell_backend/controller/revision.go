@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"bluebell_backend/logic"
+	"bluebell_backend/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// PostRevisionsHandler 查询帖子的版本列表 GET /post/:id/revisions
+func PostRevisionsHandler(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	data, err := logic.GetPostRevisions(postID)
+	if err != nil {
+		if err == logic.ErrorPostNotExist {
+			ResponseError(c, CodePostNotExist)
+			return
+		}
+		zap.L().Error("logic.GetPostRevisions failed", zap.Uint64("post_id", postID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// PostRevisionDiffHandler 比较帖子的两个版本 GET /post/:id/revisions/diff?from=1&to=2&mode=line
+func PostRevisionDiffHandler(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	p := new(models.ParamRevisionDiff)
+	if err := c.ShouldBindQuery(p); err != nil {
+		zap.L().Error("PostRevisionDiff with invalid param", zap.Error(err))
+		responseBindError(c, err)
+		return
+	}
+	data, err := logic.DiffPostRevisions(postID, p)
+	if err != nil {
+		switch err {
+		case logic.ErrorPostNotExist:
+			ResponseError(c, CodePostNotExist)
+		case logic.ErrorRevisionNotExist:
+			ResponseError(c, CodeRevisionNotExist)
+		default:
+			zap.L().Error("logic.DiffPostRevisions failed", zap.Uint64("post_id", postID), zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+		}
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// RestorePostRevisionHandler 版主将帖子恢复到指定版本 POST /post/:id/revisions/:rev/restore
+func RestorePostRevisionHandler(c *gin.Context) {
+	mc, err := getCurrentClaims(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	revision, err := strconv.ParseInt(c.Param("rev"), 10, 64)
+	if err != nil || revision < 1 {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	if err := logic.RestorePostRevision(mc, postID, revision); err != nil {
+		switch err {
+		case logic.ErrorPostNotExist:
+			ResponseError(c, CodePostNotExist)
+		case logic.ErrorRevisionNotExist:
+			ResponseError(c, CodeRevisionNotExist)
+		case logic.ErrorNoPermission:
+			ResponseError(c, CodeNoPermission)
+		default:
+			zap.L().Error("logic.RestorePostRevision failed", zap.Uint64("post_id", postID), zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+		}
+		return
+	}
+	ResponseSuccess(c, nil)
+}
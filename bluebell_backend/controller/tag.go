@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"bluebell_backend/logic"
+	"bluebell_backend/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// tagErrorCode 将标签校验错误转换为响应状态码
+func tagErrorCode(err error) (MyCode, bool) {
+	switch err {
+	case logic.ErrorInvalidTag:
+		return CodeInvalidTag, true
+	case logic.ErrorTooManyTags:
+		return CodeTooManyTags, true
+	}
+	return 0, false
+}
+
+// TagDetailHandler 标签页：按发布时间或分数排序分页获取标签下的帖子列表
+func TagDetailHandler(c *gin.Context) {
+	// GET请求参数(query string)： /api/v1/tag/golang?page=1&size=10&order=time
+	p := &models.ParamPostList{
+		Page:  1,
+		Size:  10,
+		Order: models.OrderTime,
+	}
+	if err := c.ShouldBindQuery(p); err != nil {
+		zap.L().Error("TagDetailHandler with invalid params", zap.Error(err))
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	data, err := logic.GetTagDetail(c.Param("name"), p)
+	if err != nil {
+		if code, ok := tagErrorCode(err); ok {
+			ResponseError(c, code)
+			return
+		}
+		zap.L().Error("logic.GetTagDetail failed", zap.String("tag", c.Param("name")), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// PopularTagsHandler 按帖子数量获取热门标签
+func PopularTagsHandler(c *gin.Context) {
+	size, err := strconv.ParseInt(c.DefaultQuery("size", "20"), 10, 64)
+	if err != nil || size < 1 || size > 100 {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	data, err := logic.GetPopularTags(size)
+	if err != nil {
+		zap.L().Error("logic.GetPopularTags failed", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
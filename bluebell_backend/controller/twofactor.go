@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"bluebell_backend/logic"
+	"bluebell_backend/models"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Login2FAHandler 登录第二步：提交验证码或恢复码换取Token
+func Login2FAHandler(c *gin.Context) {
+	p := new(models.ParamLogin2FA)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("Login2FA with invalid param", zap.Error(err))
+		responseBindError(c, err)
+		return
+	}
+	user, err := logic.LoginTwoFactor(p)
+	if err != nil {
+		switch err {
+		case logic.ErrorInvalidTwoFactorToken:
+			ResponseError(c, CodeInvalidTwoFactorToken)
+		case logic.ErrorInvalidTwoFactorCode:
+			ResponseError(c, CodeInvalidTwoFactorCode)
+		default:
+			zap.L().Error("logic.LoginTwoFactor failed", zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+		}
+		return
+	}
+	ResponseSuccess(c, gin.H{
+		"user_id":       fmt.Sprintf("%d", user.UserID),
+		"user_name":     user.UserName,
+		"access_token":  user.AccessToken,
+		"refresh_token": user.RefreshToken,
+	})
+}
+
+// EnrollTOTPHandler 开启两步验证第一步：生成密钥、otpauth URI和恢复码
+func EnrollTOTPHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	data, err := logic.EnrollTOTP(userID)
+	if err != nil {
+		if err == logic.ErrorTwoFactorEnabled {
+			ResponseError(c, CodeTwoFactorEnabled)
+			return
+		}
+		zap.L().Error("logic.EnrollTOTP failed", zap.Uint64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// EnableTOTPHandler 开启两步验证第二步：提交验证码确认
+func EnableTOTPHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	p := new(models.ParamTOTPCode)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("EnableTOTP with invalid param", zap.Error(err))
+		responseBindError(c, err)
+		return
+	}
+	if err := logic.EnableTOTP(userID, p.Code); err != nil {
+		switch err {
+		case logic.ErrorTwoFactorEnabled:
+			ResponseError(c, CodeTwoFactorEnabled)
+		case logic.ErrorTwoFactorNotEnabled:
+			ResponseError(c, CodeTwoFactorNotEnabled)
+		case logic.ErrorInvalidTwoFactorCode:
+			ResponseError(c, CodeInvalidTwoFactorCode)
+		default:
+			zap.L().Error("logic.EnableTOTP failed", zap.Uint64("user_id", userID), zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+		}
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// DisableTOTPHandler 校验密码后关闭两步验证
+func DisableTOTPHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	p := new(models.ParamDisable2FA)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("DisableTOTP with invalid param", zap.Error(err))
+		responseBindError(c, err)
+		return
+	}
+	if err := logic.DisableTOTP(userID, p); err != nil {
+		switch err {
+		case logic.ErrorPasswordWrong:
+			ResponseError(c, CodeInvalidPassword)
+		case logic.ErrorTwoFactorNotEnabled:
+			ResponseError(c, CodeTwoFactorNotEnabled)
+		default:
+			zap.L().Error("logic.DisableTOTP failed", zap.Uint64("user_id", userID), zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+		}
+		return
+	}
+	ResponseSuccess(c, nil)
+}
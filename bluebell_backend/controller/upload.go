@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"bluebell_backend/logic"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// UploadHandler 上传图片或附件 POST /upload multipart/form-data file字段
+// 上传后返回attachment_id，创建或编辑帖子时通过attachment_ids关联
+func UploadHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	// 限制请求体大小，预留multipart表单其他部分的空间
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, logic.MaxUploadSize()+1<<20)
+	fh, err := c.FormFile("file")
+	if err != nil {
+		zap.L().Debug("c.FormFile failed", zap.Error(err))
+		if _, ok := err.(*http.MaxBytesError); ok {
+			ResponseError(c, CodeFileTooLarge)
+			return
+		}
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	if len(fh.Filename) > logic.MaxFileNameSize {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	if fh.Size > logic.MaxUploadSize() {
+		ResponseError(c, CodeFileTooLarge)
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		zap.L().Error("open upload file failed", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	defer f.Close()
+
+	data, err := logic.Upload(userID, fh.Filename, f)
+	if err != nil {
+		switch err {
+		case logic.ErrorFileTooLarge:
+			ResponseError(c, CodeFileTooLarge)
+		case logic.ErrorFileType:
+			ResponseError(c, CodeFileTypeNotAllowed)
+		default:
+			zap.L().Error("logic.Upload failed", zap.Uint64("user_id", userID), zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+		}
+		return
+	}
+	ResponseSuccess(c, data)
+}
@@ -5,10 +5,11 @@ import (
 	"bluebell_backend/logic"
 	"bluebell_backend/models"
 	"bluebell_backend/pkg/jwt"
+	"database/sql"
 	"fmt"
 	"github.com/go-playground/validator/v10"
 	"net/http"
-	"strings"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -79,6 +80,16 @@ func LoginHandler(c *gin.Context) {
 		ResponseError(c, CodeInvalidParams)
 		return
 	}
+	// 开启两步验证时返回临时凭证，客户端提交验证码到/login/2fa完成登录
+	if user.TwoFactorToken != "" {
+		ResponseSuccess(c, gin.H{
+			"user_id":             fmt.Sprintf("%d", user.UserID),
+			"user_name":           user.UserName,
+			"two_factor_required": true,
+			"two_factor_token":    user.TwoFactorToken,
+		})
+		return
+	}
 	// 4.返回响应
 	ResponseSuccess(c, gin.H{
 		"user_id":       fmt.Sprintf("%d", user.UserID), // js识别的最大值：id值大于1<<53-1  int64: i<<63-1
@@ -90,24 +101,189 @@ func LoginHandler(c *gin.Context) {
 
 // RefreshTokenHandler 刷新accessToken
 func RefreshTokenHandler(c *gin.Context) {
+	// 1.获取参数，refresh_token已绑定用户，无需再携带过期的access_token
 	rt := c.Query("refresh_token")
-	authHeader := c.Request.Header.Get("Authorization")
-	if authHeader == "" {
-		ResponseErrorWithMsg(c, CodeInvalidToken, "请求头缺少Auth Token")
-		c.Abort()
+	if rt == "" {
+		ResponseError(c, CodeInvalidParams)
 		return
 	}
-	// 按空格分割
-	parts := strings.SplitN(authHeader, " ", 2)
-	if !(len(parts) == 2 && parts[0] == "Bearer") {
-		ResponseErrorWithMsg(c, CodeInvalidToken, "Token格式不对")
-		c.Abort()
+	// 2.业务逻辑处理——轮换Token
+	aToken, rToken, err := logic.RefreshToken(rt)
+	if err != nil {
+		switch err {
+		case logic.ErrorInvalidToken:
+			ResponseError(c, CodeInvalidToken)
+		case logic.ErrorTokenRevoked:
+			ResponseError(c, CodeTokenRevoked)
+		case logic.ErrorRefreshTokenReused:
+			ResponseError(c, CodeRefreshTokenReused)
+		default:
+			zap.L().Error("logic.RefreshToken failed", zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+		}
 		return
 	}
-	aToken, rToken, err := jwt.RefreshToken(parts[1], rt)
-	zap.L().Error("jwt.RefreshToken failed", zap.Error(err))
-	c.JSON(http.StatusOK, gin.H{
+	// 3.返回响应
+	ResponseSuccess(c, gin.H{
 		"access_token":  aToken,
 		"refresh_token": rToken,
 	})
 }
+
+// LogoutHandler 注销当前设备
+func LogoutHandler(c *gin.Context) {
+	mc, err := getCurrentClaims(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	// 吊销当前设备的Token
+	if err := logic.Logout(mc); err != nil {
+		zap.L().Error("logic.Logout failed", zap.Uint64("user_id", mc.UserID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// LogoutAllHandler 注销当前用户的所有设备
+func LogoutAllHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	if err := logic.LogoutAll(userID); err != nil {
+		zap.L().Error("logic.LogoutAll failed", zap.Uint64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// ForceLogoutHandler 管理员强制注销指定用户的所有设备
+func ForceLogoutHandler(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	if err := logic.ForceLogout(userID); err != nil {
+		zap.L().Error("logic.ForceLogout failed", zap.Uint64("user_id", userID), zap.Error(err))
+		if err == sql.ErrNoRows {
+			ResponseError(c, CodeUserNotExist)
+			return
+		}
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// VerifyEmailHandler 验证邮箱 GET /verify_email?token=xxx
+func VerifyEmailHandler(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		ResponseError(c, CodeInvalidParams)
+		return
+	}
+	if err := logic.VerifyEmail(token); err != nil {
+		if err == logic.ErrorInvalidVerifyToken {
+			ResponseError(c, CodeInvalidVerifyToken)
+			return
+		}
+		zap.L().Error("logic.VerifyEmail failed", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// ResendVerifyEmailHandler 重新发送验证邮件
+func ResendVerifyEmailHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	if err := logic.ResendVerifyEmail(userID); err != nil {
+		switch err {
+		case logic.ErrorEmailVerified:
+			ResponseError(c, CodeEmailVerified)
+		case logic.ErrorEmailMissing:
+			ResponseError(c, CodeInvalidParams)
+		default:
+			zap.L().Error("logic.ResendVerifyEmail failed", zap.Uint64("user_id", userID), zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+		}
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// JWKSHandler 公开签名公钥(JWKS)，供其他服务验证bluebell签发的Token
+func JWKSHandler(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwt.JWKS())
+}
+
+// ChangePasswordHandler 修改密码，修改成功后所有设备需要重新登录
+func ChangePasswordHandler(c *gin.Context) {
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNotLogin)
+		return
+	}
+	p := new(models.ChangePasswordForm)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("ChangePassword with invalid param", zap.Error(err))
+		responseBindError(c, err)
+		return
+	}
+	if err := logic.ChangePassword(userID, p); err != nil {
+		if err == logic.ErrorPasswordWrong {
+			ResponseError(c, CodeInvalidPassword)
+			return
+		}
+		zap.L().Error("logic.ChangePassword failed", zap.Uint64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// ForgotPasswordHandler 忘记密码，向邮箱发送重置密码链接
+func ForgotPasswordHandler(c *gin.Context) {
+	p := new(models.ForgotPasswordForm)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("ForgotPassword with invalid param", zap.Error(err))
+		responseBindError(c, err)
+		return
+	}
+	if err := logic.ForgotPassword(p); err != nil {
+		zap.L().Error("logic.ForgotPassword failed", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// ResetPasswordHandler 使用邮件中的令牌重置密码
+func ResetPasswordHandler(c *gin.Context) {
+	p := new(models.ResetPasswordForm)
+	if err := c.ShouldBindJSON(p); err != nil {
+		zap.L().Error("ResetPassword with invalid param", zap.Error(err))
+		responseBindError(c, err)
+		return
+	}
+	if err := logic.ResetPassword(p); err != nil {
+		if err == logic.ErrorInvalidResetToken {
+			ResponseError(c, CodeInvalidResetToken)
+			return
+		}
+		zap.L().Error("logic.ResetPassword failed", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
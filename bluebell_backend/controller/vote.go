@@ -66,6 +66,10 @@ func VoteHandler(c *gin.Context) {
 			ResponseError(c, ErrVoteRepeated)
 		case redis.ErrorVoteTimeExpire: // 投票超时
 			ResponseError(c, ErrorVoteTimeExpire)
+		case logic.ErrorPostNotExist:
+			ResponseError(c, CodePostNotExist)
+		case logic.ErrorPostLocked: // 帖子已锁定
+			ResponseError(c, CodePostLocked)
 		default:
 			ResponseError(c, CodeServerBusy)
 		}
@@ -3,9 +3,17 @@ CREATE TABLE `user` (
     `id` bigint(20) NOT NULL AUTO_INCREMENT,
     `user_id` bigint(20) NOT NULL,
     `username` varchar(64) COLLATE utf8mb4_general_ci NOT NULL,
-    `password` varchar(64) COLLATE utf8mb4_general_ci NOT NULL,
+    `password` varchar(255) COLLATE utf8mb4_general_ci NOT NULL,
     `email` varchar(64) COLLATE utf8mb4_general_ci,
+    `email_verified` tinyint(1) NOT NULL DEFAULT '0',
     `gender` tinyint(4) NOT NULL DEFAULT '0',
+    `totp_secret` varchar(64) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '两步验证密钥',
+    `totp_enabled` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否开启两步验证',
+    `totp_last_counter` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT '最近一次使用的验证码时间步',
+    `role` varchar(16) COLLATE utf8mb4_general_ci NOT NULL DEFAULT 'user' COMMENT '角色 user/moderator/admin',
+    `display_name` varchar(64) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '',
+    `bio` varchar(512) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '',
+    `avatar_url` varchar(256) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '',
     `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
     `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     PRIMARY KEY (`id`),
@@ -14,6 +22,18 @@ CREATE TABLE `user` (
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
 
 
+DROP TABLE IF EXISTS `user_recovery_code`;
+CREATE TABLE `user_recovery_code` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `user_id` bigint(20) NOT NULL,
+  `code_hash` char(64) COLLATE utf8mb4_general_ci NOT NULL COMMENT '恢复码的sha256',
+  `used_time` timestamp NULL DEFAULT NULL COMMENT '使用时间，未使用为NULL',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_user_code` (`user_id`,`code_hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+
 DROP TABLE IF EXISTS `community`;
 CREATE TABLE `community` (
   `id` int(11) NOT NULL AUTO_INCREMENT,
@@ -28,6 +48,20 @@ CREATE TABLE `community` (
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
 
 
+-- 初始化管理员：update user set role = 'admin' where username = '<username>';
+
+DROP TABLE IF EXISTS `community_moderator`;
+CREATE TABLE `community_moderator` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `community_id` int(10) unsigned NOT NULL COMMENT '社区id',
+  `user_id` bigint(20) NOT NULL COMMENT '版主的用户id',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_community_user` (`community_id`,`user_id`),
+  KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+
 DROP TABLE IF EXISTS `post`;
 CREATE TABLE `post` (
   `id` bigint(20) NOT NULL AUTO_INCREMENT,
@@ -36,13 +70,92 @@ CREATE TABLE `post` (
   `content` varchar(8192) COLLATE utf8mb4_general_ci NOT NULL COMMENT '内容',
   `author_id` bigint(20) NOT NULL COMMENT '作者的用户id',
   `community_id` bigint(20) NOT NULL COMMENT '所属社区',
-  `status` tinyint(4) NOT NULL DEFAULT '1' COMMENT '帖子状态',
+  `status` tinyint(4) NOT NULL DEFAULT '1' COMMENT '帖子状态 0:已删除 1:已发布 2:草稿 3:定时发布',
+  `publish_at` timestamp NULL DEFAULT NULL COMMENT '发布时间',
+  `pinned` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否在社区中置顶',
+  `locked` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否锁定，锁定后不允许评论和投票',
+  `featured` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否精华帖',
+  `view_num` bigint(20) NOT NULL DEFAULT '0' COMMENT '浏览数(每天按独立访客计数)',
   `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
   `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
   PRIMARY KEY (`id`),
   UNIQUE KEY `idx_post_id` (`post_id`),
   KEY `idx_author_id` (`author_id`),
-  KEY `idx_community_id` (`community_id`)
+  KEY `idx_community_id` (`community_id`),
+  KEY `idx_status_publish_at` (`status`,`publish_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+
+DROP TABLE IF EXISTS `post_tag`;
+CREATE TABLE `post_tag` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `post_id` bigint(20) NOT NULL COMMENT '帖子id',
+  `tag_name` varchar(32) COLLATE utf8mb4_general_ci NOT NULL COMMENT '标签，已规范化为小写',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_post_tag` (`post_id`,`tag_name`),
+  KEY `idx_tag_name` (`tag_name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+
+DROP TABLE IF EXISTS `poll`;
+CREATE TABLE `poll` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `post_id` bigint(20) NOT NULL COMMENT '帖子id，每篇帖子最多一个投票',
+  `multiple` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否允许多选',
+  `close_at` timestamp NULL DEFAULT NULL COMMENT '截止时间',
+  `closed` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否已截止，截止后结果保存在poll_option中',
+  `voters` int(11) NOT NULL DEFAULT '0' COMMENT '截止时参与投票的人数',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_post_id` (`post_id`),
+  KEY `idx_closed_close_at` (`closed`,`close_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+
+DROP TABLE IF EXISTS `poll_option`;
+CREATE TABLE `poll_option` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `post_id` bigint(20) NOT NULL COMMENT '帖子id',
+  `option_index` tinyint(4) NOT NULL COMMENT '选项序号，从0开始',
+  `content` varchar(128) COLLATE utf8mb4_general_ci NOT NULL COMMENT '选项内容',
+  `votes` int(11) NOT NULL DEFAULT '0' COMMENT '截止时的得票数',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_post_option` (`post_id`,`option_index`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+
+DROP TABLE IF EXISTS `attachment`;
+CREATE TABLE `attachment` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `attachment_id` bigint(20) NOT NULL COMMENT '附件id',
+  `user_id` bigint(20) NOT NULL COMMENT '上传者的用户id',
+  `post_id` bigint(20) NOT NULL DEFAULT '0' COMMENT '关联的帖子id，0表示未关联',
+  `file_name` varchar(255) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '上传时的文件名',
+  `content_type` varchar(64) COLLATE utf8mb4_general_ci NOT NULL COMMENT '文件类型',
+  `size` bigint(20) NOT NULL COMMENT '文件大小(字节)',
+  `width` int(11) NOT NULL DEFAULT '0' COMMENT '图片宽度',
+  `height` int(11) NOT NULL DEFAULT '0' COMMENT '图片高度',
+  `storage_key` varchar(255) COLLATE utf8mb4_general_ci NOT NULL COMMENT '文件在存储中的key',
+  `thumb_key` varchar(255) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '缩略图在存储中的key',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_attachment_id` (`attachment_id`),
+  KEY `idx_post_id_create_time` (`post_id`,`create_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+
+DROP TABLE IF EXISTS `post_revision`;
+CREATE TABLE `post_revision` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `post_id` bigint(20) NOT NULL COMMENT '帖子id',
+  `revision` int(11) NOT NULL COMMENT '版本号，从1开始',
+  `title` varchar(128) COLLATE utf8mb4_general_ci NOT NULL COMMENT '标题',
+  `content` varchar(8192) COLLATE utf8mb4_general_ci NOT NULL COMMENT '内容',
+  `editor_id` bigint(20) NOT NULL COMMENT '编辑者的用户id',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_post_revision` (`post_id`,`revision`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
 
 
@@ -54,10 +167,55 @@ CREATE TABLE `comment` (
   `post_id` bigint(20) NOT NULL,
   `author_id` bigint(20) NOT NULL,
   `parent_id` bigint(20) NOT NULL DEFAULT '0',
-  `status` tinyint(3) unsigned NOT NULL DEFAULT '1',
+  `status` tinyint(3) unsigned NOT NULL DEFAULT '1' COMMENT '评论状态 0:已删除 1:正常',
+  `edit_time` timestamp NULL DEFAULT NULL COMMENT '最后编辑时间',
   `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
   `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   PRIMARY KEY (`id`),
   UNIQUE KEY `idx_comment_id` (`comment_id`),
+  KEY `idx_post_parent_time` (`post_id`,`parent_id`,`create_time`),
   KEY `idx_author_Id` (`author_id`)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
\ No newline at end of file
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+DROP TABLE IF EXISTS `bookmark_collection`;
+CREATE TABLE `bookmark_collection` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `collection_id` bigint(20) NOT NULL COMMENT '收藏夹id',
+  `user_id` bigint(20) NOT NULL COMMENT '用户id',
+  `name` varchar(32) COLLATE utf8mb4_general_ci NOT NULL COMMENT '收藏夹名称',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_collection_id` (`collection_id`),
+  UNIQUE KEY `idx_user_name` (`user_id`,`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+
+DROP TABLE IF EXISTS `bookmark`;
+CREATE TABLE `bookmark` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `user_id` bigint(20) NOT NULL COMMENT '用户id',
+  `post_id` bigint(20) NOT NULL COMMENT '帖子id',
+  `collection_id` bigint(20) NOT NULL DEFAULT '0' COMMENT '所属收藏夹id，0表示未放入收藏夹',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '收藏时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_user_post` (`user_id`,`post_id`),
+  KEY `idx_user_collection_time` (`user_id`,`collection_id`,`create_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+
+DROP TABLE IF EXISTS `notification`;
+CREATE TABLE `notification` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `notification_id` bigint(20) NOT NULL COMMENT '通知id',
+  `user_id` bigint(20) NOT NULL COMMENT '接收通知的用户id',
+  `type` varchar(16) COLLATE utf8mb4_general_ci NOT NULL COMMENT '通知类型 reply_post/reply_comment/mention',
+  `actor_id` bigint(20) NOT NULL COMMENT '发表评论的用户id',
+  `post_id` bigint(20) NOT NULL COMMENT '帖子id',
+  `comment_id` bigint(20) NOT NULL COMMENT '评论id',
+  `is_read` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否已读',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_notification_id` (`notification_id`),
+  UNIQUE KEY `idx_user_comment` (`user_id`,`comment_id`),
+  KEY `idx_user_read_time` (`user_id`,`is_read`,`create_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
@@ -0,0 +1,105 @@
+package mysql
+
+import (
+	"bluebell_backend/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// CreateAttachment 保存上传的附件，此时尚未关联帖子
+func CreateAttachment(a *models.Attachment) (err error) {
+	sqlStr := `insert into attachment(
+	attachment_id, user_id, file_name, content_type, size, width, height, storage_key, thumb_key)
+	values(?,?,?,?,?,?,?,?,?)`
+	_, err = db.Exec(sqlStr, a.AttachmentID, a.UserID, a.FileName, a.ContentType, a.Size,
+		a.Width, a.Height, a.StorageKey, a.ThumbKey)
+	return
+}
+
+// linkAttachments 在事务中将用户上传的附件关联到帖子，附件不属于该用户或已关联其他帖子时返回错误
+func linkAttachments(tx *sqlx.Tx, postID, userID uint64, ids []uint64) (err error) {
+	if len(ids) == 0 {
+		return
+	}
+	// 去重，避免重复的id导致影响行数与期望不一致
+	seen := make(map[uint64]bool, len(ids))
+	uniq := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			uniq = append(uniq, id)
+		}
+	}
+	// 锁定有效的附件，全部有效时才关联
+	query, args, err := sqlx.In(`select count(*) from attachment
+	where attachment_id in (?) and user_id = ? and post_id in (0, ?)
+	for update`, uniq, userID, postID)
+	if err != nil {
+		return
+	}
+	var count int
+	if err = tx.Get(&count, tx.Rebind(query), args...); err != nil {
+		return
+	}
+	if count != len(uniq) {
+		return ErrorInvalidAttachment
+	}
+	query, args, err = sqlx.In(`update attachment set post_id = ? where attachment_id in (?)`, postID, uniq)
+	if err != nil {
+		return
+	}
+	_, err = tx.Exec(tx.Rebind(query), args...)
+	return
+}
+
+// LinkAttachments 将用户上传的附件关联到帖子
+func LinkAttachments(postID, userID uint64, ids []uint64) (err error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if err = linkAttachments(tx, postID, userID, ids); err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+// GetPostAttachments 查询帖子的附件
+func GetPostAttachments(postID uint64) (attachments []*models.Attachment, err error) {
+	sqlStr := `select attachment_id, user_id, post_id, file_name, content_type, size, width, height,
+	storage_key, thumb_key, create_time
+	from attachment
+	where post_id = ?
+	order by id`
+	attachments = make([]*models.Attachment, 0)
+	err = db.Select(&attachments, sqlStr, postID)
+	return
+}
+
+// GetOrphanAttachments 查询在before之前上传且未关联帖子的附件
+func GetOrphanAttachments(before time.Time, limit int) (attachments []*models.Attachment, err error) {
+	sqlStr := `select attachment_id, user_id, post_id, file_name, content_type, size, width, height,
+	storage_key, thumb_key, create_time
+	from attachment
+	where post_id = 0 and create_time < ?
+	order by create_time
+	limit ?`
+	err = db.Select(&attachments, sqlStr, before, limit)
+	return
+}
+
+// DeleteOrphanAttachment 删除未关联帖子的附件记录，附件在此期间被关联时返回false
+func DeleteOrphanAttachment(attachmentID uint64) (ok bool, err error) {
+	ret, err := db.Exec(`delete from attachment where attachment_id = ? and post_id = 0`, attachmentID)
+	if err != nil {
+		return
+	}
+	n, err := ret.RowsAffected()
+	return n > 0, err
+}
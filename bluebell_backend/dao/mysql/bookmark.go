@@ -0,0 +1,126 @@
+package mysql
+
+import (
+	"bluebell_backend/models"
+	"errors"
+	"strconv"
+
+	driver "github.com/go-sql-driver/mysql"
+)
+
+// AddBookmark 收藏帖子，已收藏时只更新所属收藏夹，收藏时间不变
+func AddBookmark(userID, postID, collectionID uint64) (err error) {
+	sqlStr := `insert into bookmark(user_id, post_id, collection_id) values(?,?,?)
+	on duplicate key update collection_id = values(collection_id)`
+	_, err = db.Exec(sqlStr, userID, postID, collectionID)
+	return
+}
+
+// RemoveBookmark 取消收藏帖子
+func RemoveBookmark(userID, postID uint64) (err error) {
+	_, err = db.Exec(`delete from bookmark where user_id = ? and post_id = ?`, userID, postID)
+	return
+}
+
+// IsBookmarked 查询用户是否收藏了帖子
+func IsBookmarked(userID, postID uint64) (bookmarked bool, err error) {
+	sqlStr := `select count(*) from bookmark where user_id = ? and post_id = ?`
+	var count int64
+	err = db.Get(&count, sqlStr, userID, postID)
+	return count > 0, err
+}
+
+// GetBookmarks 按收藏时间降序查询用户收藏的所有帖子，用于重建redis缓存
+func GetBookmarks(userID uint64) (bookmarks []*models.Bookmark, err error) {
+	sqlStr := `select user_id, post_id, collection_id, create_time
+	from bookmark
+	where user_id = ?
+	order by create_time desc, id desc`
+	bookmarks = make([]*models.Bookmark, 0)
+	err = db.Select(&bookmarks, sqlStr, userID)
+	return
+}
+
+// GetCollectionBookmarkIDs 按收藏时间降序分页查询收藏夹中的帖子ids及总数
+func GetCollectionBookmarkIDs(userID, collectionID uint64, page, size int64) (ids []string, total int64, err error) {
+	sqlStr := `select count(*) from bookmark where user_id = ? and collection_id = ?`
+	if err = db.Get(&total, sqlStr, userID, collectionID); err != nil {
+		return
+	}
+	sqlStr = `select post_id
+	from bookmark
+	where user_id = ? and collection_id = ?
+	order by create_time desc, id desc
+	limit ?,?`
+	var postIDs []uint64
+	if err = db.Select(&postIDs, sqlStr, userID, collectionID, (page-1)*size, size); err != nil {
+		return
+	}
+	ids = make([]string, 0, len(postIDs))
+	for _, id := range postIDs {
+		ids = append(ids, strconv.FormatUint(id, 10))
+	}
+	return
+}
+
+// CreateCollection 创建收藏夹，同一用户的收藏夹不能重名
+func CreateCollection(collection *models.BookmarkCollection) (err error) {
+	sqlStr := `insert into bookmark_collection(collection_id, user_id, name) values(?,?,?)`
+	_, err = db.Exec(sqlStr, collection.CollectionID, collection.UserID, collection.Name)
+	if err != nil {
+		var me *driver.MySQLError
+		if errors.As(err, &me) && me.Number == 1062 { // 违反唯一索引：收藏夹重名
+			return ErrorCollectionExist
+		}
+	}
+	return
+}
+
+// GetCollections 查询用户的收藏夹及每个收藏夹中的帖子数量
+func GetCollections(userID uint64) (collections []*models.BookmarkCollection, err error) {
+	sqlStr := `select c.collection_id, c.user_id, c.name, c.create_time, count(b.id) as post_count
+	from bookmark_collection c
+	left join bookmark b on b.user_id = c.user_id and b.collection_id = c.collection_id
+	where c.user_id = ?
+	group by c.id
+	order by c.id`
+	collections = make([]*models.BookmarkCollection, 0)
+	err = db.Select(&collections, sqlStr, userID)
+	return
+}
+
+// CollectionExists 查询收藏夹是否存在且属于该用户
+func CollectionExists(userID, collectionID uint64) (exists bool, err error) {
+	sqlStr := `select count(*) from bookmark_collection where collection_id = ? and user_id = ?`
+	var count int64
+	err = db.Get(&count, sqlStr, collectionID, userID)
+	return count > 0, err
+}
+
+// DeleteCollection 删除收藏夹，其中的帖子仍保留在收藏中；返回收藏夹是否存在
+func DeleteCollection(userID, collectionID uint64) (deleted bool, err error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	ret, err := tx.Exec(`delete from bookmark_collection where collection_id = ? and user_id = ?`, collectionID, userID)
+	if err != nil {
+		return
+	}
+	n, err := ret.RowsAffected()
+	if err != nil {
+		return
+	}
+	if n == 0 {
+		return false, tx.Rollback()
+	}
+	if _, err = tx.Exec(`update bookmark set collection_id = 0 where user_id = ? and collection_id = ?`, userID, collectionID); err != nil {
+		return
+	}
+	return true, tx.Commit()
+}
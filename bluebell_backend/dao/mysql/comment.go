@@ -24,9 +24,9 @@ func CreateComment(comment *models.Comment) (err error) {
 }
 
 func GetCommentListByIDs(ids []string) (commentList []*models.Comment, err error) {
-	sqlStr := `select comment_id, content, post_id, author_id, parent_id, create_time
+	sqlStr := `select comment_id, content, post_id, author_id, parent_id, status, edit_time, create_time
 	from comment
-	where comment_id in (?)`
+	where comment_id in (?) and status = 1`
 	// 使用 sqlx.In 动态生成带有占位符的SQL查询语句，并将参数绑定到查询中
 	query, args, err := sqlx.In(sqlStr, ids)
 	if err != nil {
@@ -37,3 +37,151 @@ func GetCommentListByIDs(ids []string) (commentList []*models.Comment, err error
 	err = db.Select(&commentList, query, args...)
 	return
 }
+
+// GetCommentByID 根据comment_id查询评论
+func GetCommentByID(commentID uint64) (comment *models.Comment, err error) {
+	comment = new(models.Comment)
+	sqlStr := `select comment_id, content, post_id, author_id, parent_id, status, edit_time, create_time
+	from comment
+	where comment_id = ? and status = 1`
+	err = db.Get(comment, sqlStr, commentID)
+	return
+}
+
+// RemoveComment 删除评论：将评论状态置为0，评论已被删除时removed为false
+func RemoveComment(commentID uint64) (removed bool, err error) {
+	sqlStr := `update comment set status = 0 where comment_id = ? and status = 1`
+	ret, err := db.Exec(sqlStr, commentID)
+	if err != nil {
+		return
+	}
+	n, err := ret.RowsAffected()
+	return n > 0, err
+}
+
+// UpdateComment 编辑评论内容并记录编辑时间
+func UpdateComment(commentID uint64, content string) (err error) {
+	sqlStr := `update comment set content = ?, edit_time = now() where comment_id = ? and status = 1`
+	_, err = db.Exec(sqlStr, content, commentID)
+	return
+}
+
+// HasReplies 查询评论是否有回复，包括已删除的回复
+func HasReplies(postID, commentID uint64) (has bool, err error) {
+	sqlStr := `select exists(select 1 from comment where post_id = ? and parent_id = ?)`
+	err = db.Get(&has, sqlStr, postID, commentID)
+	return
+}
+
+// commentVisible 评论树中显示的评论：未删除，或已删除但仍有回复(显示为[deleted])
+const commentVisible = `(c.status = 1 or exists(select 1 from comment r where r.post_id = c.post_id and r.parent_id = c.comment_id))`
+
+// GetCommentTreeByIDs 根据ids查询评论树中的评论，包括已删除但仍有回复的评论
+func GetCommentTreeByIDs(ids []string) (comments []*models.Comment, err error) {
+	sqlStr := `select c.comment_id, c.content, c.post_id, c.author_id, c.parent_id, c.status, c.edit_time, c.create_time
+	from comment c
+	where c.comment_id in (?) and ` + commentVisible
+	query, args, err := sqlx.In(sqlStr, ids)
+	if err != nil {
+		return
+	}
+	err = db.Select(&comments, db.Rebind(query), args...)
+	return
+}
+
+// GetTopCommentTimes 查询帖子中评论树显示的一级评论及发布时间，用于补充redis中一级评论的排序
+func GetTopCommentTimes(postID uint64) (comments []*models.Comment, err error) {
+	sqlStr := `select c.comment_id, c.create_time
+	from comment c
+	where c.post_id = ? and c.parent_id = 0 and ` + commentVisible
+	comments = make([]*models.Comment, 0)
+	err = db.Select(&comments, sqlStr, postID)
+	return
+}
+
+// GetReplyIDs 分页查询帖子中某条评论的回复ids及总数，parentID为0时查询一级评论，包括已删除但仍有回复的评论
+// 一级评论按发布时间降序排列，回复按发布时间升序排列
+func GetReplyIDs(postID, parentID uint64, page, size int64) (ids []string, total int64, err error) {
+	sqlStr := `select count(*) from comment c where c.post_id = ? and c.parent_id = ? and ` + commentVisible
+	if err = db.Get(&total, sqlStr, postID, parentID); err != nil {
+		return
+	}
+	order := "asc"
+	if parentID == 0 {
+		order = "desc"
+	}
+	sqlStr = `select c.comment_id
+	from comment c
+	where c.post_id = ? and c.parent_id = ? and ` + commentVisible + `
+	order by c.create_time ` + order + `, c.id ` + order + `
+	limit ?,?`
+	ids = make([]string, 0, size)
+	err = db.Select(&ids, sqlStr, postID, parentID, (page-1)*size, size)
+	return
+}
+
+// GetRepliesByParentIDs 查询帖子中多条评论的直接回复，每条评论最多返回perParent条最早的回复，总数不超过limit
+// 按回复在各自评论中的序号优先，保证回复数量多的评论不会占满limit
+func GetRepliesByParentIDs(postID uint64, parentIDs []uint64, perParent, limit int) (comments []*models.Comment, err error) {
+	comments = make([]*models.Comment, 0)
+	if len(parentIDs) == 0 || perParent < 1 || limit < 1 {
+		return
+	}
+	sqlStr := `select comment_id, content, post_id, author_id, parent_id, status, edit_time, create_time
+	from (
+		select c.comment_id, c.content, c.post_id, c.author_id, c.parent_id, c.status, c.edit_time, c.create_time, c.id,
+		row_number() over (partition by c.parent_id order by c.create_time, c.id) as rn
+		from comment c
+		where c.post_id = ? and c.parent_id in (?) and ` + commentVisible + `
+	) t
+	where rn <= ?
+	order by rn, create_time, id
+	limit ?`
+	query, args, err := sqlx.In(sqlStr, postID, parentIDs, perParent, limit)
+	if err != nil {
+		return
+	}
+	err = db.Select(&comments, db.Rebind(query), args...)
+	return
+}
+
+// CountRepliesByParentIDs 统计帖子中多条评论的直接回复数量，返回comment_id到回复数量的映射
+func CountRepliesByParentIDs(postID uint64, parentIDs []uint64) (counts map[uint64]int64, err error) {
+	counts = make(map[uint64]int64, len(parentIDs))
+	if len(parentIDs) == 0 {
+		return
+	}
+	sqlStr := `select c.parent_id, count(*) as reply_num
+	from comment c
+	where c.post_id = ? and c.parent_id in (?) and ` + commentVisible + `
+	group by c.parent_id`
+	query, args, err := sqlx.In(sqlStr, postID, parentIDs)
+	if err != nil {
+		return
+	}
+	var rows []struct {
+		ParentID uint64 `db:"parent_id"`
+		ReplyNum int64  `db:"reply_num"`
+	}
+	if err = db.Select(&rows, db.Rebind(query), args...); err != nil {
+		return
+	}
+	for _, row := range rows {
+		counts[row.ParentID] = row.ReplyNum
+	}
+	return
+}
+
+// GetPostCommentNums 按post_id升序统计已发布帖子中未删除的评论数量，从afterID之后开始查询limit篇帖子
+func GetPostCommentNums(afterID uint64, limit int) (nums []*models.PostCommentNum, err error) {
+	sqlStr := `select p.post_id, count(c.id) as comment_num
+	from post p
+	left join comment c on c.post_id = p.post_id and c.status = 1
+	where p.status = 1 and p.post_id > ?
+	group by p.post_id
+	order by p.post_id
+	limit ?`
+	nums = make([]*models.PostCommentNum, 0, limit)
+	err = db.Select(&nums, sqlStr, afterID, limit)
+	return
+}
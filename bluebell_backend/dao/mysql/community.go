@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 
+	driver "github.com/go-sql-driver/mysql"
 	"go.uber.org/zap"
 )
 
@@ -59,3 +60,52 @@ func GetCommunityByID(id uint64) (*models.CommunityDetailRes, error) {
 		CreateTime:    community.CreateTime.Format("2006-01-02 15:04:05"),
 	}, err
 }
+
+// CreateCommunity 创建社区，社区ID在当前最大ID的基础上递增
+func CreateCommunity(p *models.ParamCreateCommunity) (communityID uint64, err error) {
+	sqlStr := `insert into community(community_id, community_name, introduction)
+	select ifnull(max(community_id), 0) + 1, ?, ? from community`
+	ret, err := db.Exec(sqlStr, p.CommunityName, p.Introduction)
+	if err != nil {
+		var me *driver.MySQLError
+		if errors.As(err, &me) && me.Number == 1062 { // 违反唯一索引：社区名重复
+			return 0, ErrorCommunityExist
+		}
+		return 0, err
+	}
+	id, err := ret.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	err = db.Get(&communityID, `select community_id from community where id = ?`, id)
+	return
+}
+
+// AddCommunityModerator 指派社区版主
+func AddCommunityModerator(communityID, userID uint64) (err error) {
+	sqlStr := `insert ignore into community_moderator(community_id, user_id) values(?,?)`
+	_, err = db.Exec(sqlStr, communityID, userID)
+	return
+}
+
+// RemoveCommunityModerator 撤销社区版主
+func RemoveCommunityModerator(communityID, userID uint64) (err error) {
+	sqlStr := `delete from community_moderator where community_id = ? and user_id = ?`
+	_, err = db.Exec(sqlStr, communityID, userID)
+	return
+}
+
+// IsCommunityModerator 判断用户是否为社区的版主
+func IsCommunityModerator(communityID, userID uint64) (ok bool, err error) {
+	var count int64
+	sqlStr := `select count(id) from community_moderator where community_id = ? and user_id = ?`
+	err = db.Get(&count, sqlStr, communityID, userID)
+	return count > 0, err
+}
+
+// GetModeratedCommunityIDs 查询用户担任版主的社区
+func GetModeratedCommunityIDs(userID uint64) (ids []uint64, err error) {
+	sqlStr := `select community_id from community_moderator where user_id = ?`
+	err = db.Select(&ids, sqlStr, userID)
+	return
+}
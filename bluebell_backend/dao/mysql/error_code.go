@@ -11,4 +11,8 @@ var (
 	ErrorInvalidID     = "无效的ID"
 	ErrorQueryFailed   = "查询数据失败"
 	ErrorInsertFailed  = errors.New("插入数据失败")
+
+	ErrorCommunityExist    = errors.New("社区已存在")
+	ErrorInvalidAttachment = errors.New("附件不存在或已被其他帖子使用")
+	ErrorCollectionExist   = errors.New("收藏夹已存在")
 )
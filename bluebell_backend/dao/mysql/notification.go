@@ -0,0 +1,66 @@
+package mysql
+
+import (
+	"bluebell_backend/models"
+)
+
+// CreateNotification 保存通知，同一评论对同一用户只通知一次，inserted为false表示通知已存在
+func CreateNotification(n *models.Notification) (inserted bool, err error) {
+	sqlStr := `insert ignore into notification(notification_id, user_id, type, actor_id, post_id, comment_id)
+	values(?,?,?,?,?,?)`
+	ret, err := db.Exec(sqlStr, n.NotificationID, n.UserID, n.Type, n.ActorID, n.PostID, n.CommentID)
+	if err != nil {
+		return
+	}
+	rows, err := ret.RowsAffected()
+	return rows > 0, err
+}
+
+// GetNotifications 按时间降序分页查询用户的通知及总数，unread为true时只查询未读通知
+func GetNotifications(userID uint64, unread bool, page, size int64) (list []*models.Notification, total int64, err error) {
+	where := `where user_id = ?`
+	if unread {
+		where += ` and is_read = 0`
+	}
+	if err = db.Get(&total, `select count(*) from notification `+where, userID); err != nil {
+		return
+	}
+	sqlStr := `select notification_id, user_id, type, actor_id, post_id, comment_id, is_read, create_time
+	from notification ` + where + `
+	order by create_time desc, id desc
+	limit ?,?`
+	list = make([]*models.Notification, 0, size)
+	err = db.Select(&list, sqlStr, userID, (page-1)*size, size)
+	return
+}
+
+// CountUnreadNotifications 统计用户的未读通知数量
+func CountUnreadNotifications(userID uint64) (count int64, err error) {
+	sqlStr := `select count(*) from notification where user_id = ? and is_read = 0`
+	err = db.Get(&count, sqlStr, userID)
+	return
+}
+
+// MarkNotificationRead 将用户的一条通知标记为已读，exists为false表示通知不存在，updated表示是否由未读变为已读
+func MarkNotificationRead(userID, notificationID uint64) (exists, updated bool, err error) {
+	sqlStr := `update notification set is_read = 1 where notification_id = ? and user_id = ? and is_read = 0`
+	ret, err := db.Exec(sqlStr, notificationID, userID)
+	if err != nil {
+		return
+	}
+	rows, err := ret.RowsAffected()
+	if err != nil || rows > 0 {
+		return rows > 0, rows > 0, err
+	}
+	var count int64
+	sqlStr = `select count(*) from notification where notification_id = ? and user_id = ?`
+	err = db.Get(&count, sqlStr, notificationID, userID)
+	return count > 0, false, err
+}
+
+// MarkAllNotificationsRead 将用户的所有通知标记为已读
+func MarkAllNotificationsRead(userID uint64) (err error) {
+	sqlStr := `update notification set is_read = 1 where user_id = ? and is_read = 0`
+	_, err = db.Exec(sqlStr, userID)
+	return
+}
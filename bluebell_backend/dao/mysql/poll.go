@@ -0,0 +1,84 @@
+package mysql
+
+import (
+	"bluebell_backend/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// insertPoll 在事务中保存帖子的投票及选项
+func insertPoll(tx *sqlx.Tx, postID uint64, p *models.ParamPoll) (err error) {
+	sqlStr := `insert into poll(post_id, multiple, close_at) values(?,?,?)`
+	if _, err = tx.Exec(sqlStr, postID, p.Multiple, p.CloseAt); err != nil {
+		return
+	}
+	sqlStr = `insert into poll_option(post_id, option_index, content) values(?,?,?)`
+	for i, option := range p.Options {
+		if _, err = tx.Exec(sqlStr, postID, i, option); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// GetPoll 查询帖子的投票，帖子没有投票时返回sql.ErrNoRows
+func GetPoll(postID uint64) (poll *models.Poll, err error) {
+	poll = new(models.Poll)
+	sqlStr := `select post_id, multiple, close_at, closed, voters from poll where post_id = ?`
+	err = db.Get(poll, sqlStr, postID)
+	return
+}
+
+// GetPollOptions 查询投票的选项，截止后的票数为最终结果
+func GetPollOptions(postID uint64) (options []*models.PollOption, err error) {
+	sqlStr := `select post_id, option_index, content, votes
+	from poll_option
+	where post_id = ?
+	order by option_index`
+	options = make([]*models.PollOption, 0)
+	err = db.Select(&options, sqlStr, postID)
+	return
+}
+
+// GetDuePolls 查询已到截止时间但尚未保存结果的投票，只包括已发布的帖子
+func GetDuePolls(now time.Time, limit int) (postIDs []uint64, err error) {
+	sqlStr := `select poll.post_id
+	from poll join post on poll.post_id = post.post_id
+	where poll.closed = 0 and poll.close_at <= ? and post.status = 1
+	order by poll.close_at
+	limit ?`
+	err = db.Select(&postIDs, sqlStr, now, limit)
+	return
+}
+
+// ClosePoll 保存投票的最终结果，投票已截止时返回false
+func ClosePoll(postID uint64, votes map[int]int64, voters int64) (ok bool, err error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	sqlStr := `update poll set closed = 1, voters = ?, close_at = ifnull(close_at, now())
+	where post_id = ? and closed = 0`
+	ret, err := tx.Exec(sqlStr, voters, postID)
+	if err != nil {
+		return
+	}
+	n, err := ret.RowsAffected()
+	if err != nil || n == 0 {
+		_ = tx.Rollback()
+		return false, err
+	}
+	for index, count := range votes {
+		if _, err = tx.Exec(`update poll_option set votes = ? where post_id = ? and option_index = ?`,
+			count, postID, index); err != nil {
+			return
+		}
+	}
+	return true, tx.Commit()
+}
@@ -6,13 +6,14 @@ import (
 	"errors"
 	"github.com/jmoiron/sqlx"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 // GetPostTotalCount 查询数据库帖子总数
 func GetPostTotalCount() (count int64, err error) {
-	sqlStr := `select count(post_id) from post`
+	sqlStr := `select count(post_id) from post where status = 1`
 	err = db.Get(&count, sqlStr)
 	if err != nil {
 		zap.L().Error("db.Get(&count, sqlStr) failed", zap.Error(err))
@@ -23,7 +24,7 @@ func GetPostTotalCount() (count int64, err error) {
 
 // GetCommunityPostTotalCount 根据社区Id查询数据库帖子总数
 func GetCommunityPostTotalCount(communityID uint64) (count int64, err error) {
-	sqlStr := `select count(post_id) from post where community_id = ?`
+	sqlStr := `select count(post_id) from post where community_id = ? and status = 1`
 	err = db.Get(&count, sqlStr, communityID)
 	if err != nil {
 		zap.L().Error("db.Get(&count, sqlStr) failed", zap.Error(err))
@@ -32,26 +33,52 @@ func GetCommunityPostTotalCount(communityID uint64) (count int64, err error) {
 	return
 }
 
-// CreatePost 创建帖子
+// CreatePost 创建帖子，同时保存为帖子的第1个版本
 func CreatePost(post *models.Post) (err error) {
-	sqlStr := `insert into post(
-	post_id, title, content, author_id, community_id)
-	values(?,?,?,?,?)`
-	_, err = db.Exec(sqlStr, post.PostID, post.Title, post.Content, post.AuthorId, post.CommunityID)
+	tx, err := db.Beginx()
 	if err != nil {
-		zap.L().Error("insert post failed", zap.Error(err))
-		err = ErrorInsertFailed
 		return
 	}
-	return nil
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			if err == ErrorInvalidAttachment {
+				return
+			}
+			zap.L().Error("insert post failed", zap.Error(err))
+			err = ErrorInsertFailed
+		}
+	}()
+	sqlStr := `insert into post(
+	post_id, title, content, author_id, community_id, status, publish_at)
+	values(?,?,?,?,?,?,?)`
+	if _, err = tx.Exec(sqlStr, post.PostID, post.Title, post.Content, post.AuthorId, post.CommunityID,
+		post.Status, post.PublishAt); err != nil {
+		return
+	}
+	if err = insertRevision(tx, post.PostID, 1, post.Title, post.Content, post.AuthorId); err != nil {
+		return
+	}
+	if err = insertPostTags(tx, post.PostID, post.Tags); err != nil {
+		return
+	}
+	if err = linkAttachments(tx, post.PostID, post.AuthorId, post.AttachmentIDs); err != nil {
+		return
+	}
+	if post.Poll != nil {
+		if err = insertPoll(tx, post.PostID, post.Poll); err != nil {
+			return
+		}
+	}
+	return tx.Commit()
 }
 
 // GetPostByID 根据post_id查询帖子详情
 func GetPostByID(pid int64) (post *models.Post, err error) {
 	post = new(models.Post)
-	sqlStr := `select post_id, title, content, author_id, community_id, status, create_time, update_time
+	sqlStr := `select post_id, title, content, author_id, community_id, pinned, locked, featured, view_num, status, create_time, update_time
 	from post
-	where post_id = ?`
+	where post_id = ? and status = 1`
 	err = db.Get(post, sqlStr, pid)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -65,9 +92,9 @@ func GetPostByID(pid int64) (post *models.Post, err error) {
 
 // GetPostListByIDs 根据给定的ids查询帖子数据
 func GetPostListByIDs(ids []string) (postList []*models.Post, err error) {
-	sqlStr := `select post_id, title, content, author_id, community_id, create_time
+	sqlStr := `select post_id, title, content, author_id, community_id, pinned, locked, featured, view_num, create_time
 	from post
-	where post_id in (?)
+	where post_id in (?) and status = 1
 	order by FIND_IN_SET(post_id, ?)` // 确保结果按传入的ids顺序返回
 	// 将ids拼接成逗号分隔的字符串
 	query, args, err := sqlx.In(sqlStr, ids, strings.Join(ids, ","))
@@ -82,8 +109,9 @@ func GetPostListByIDs(ids []string) (postList []*models.Post, err error) {
 
 // GetPostList 获取帖子列表
 func GetPostList(page, size int64) (posts []*models.Post, err error) {
-	sqlStr := `select post_id, title, content, author_id, community_id, create_time
+	sqlStr := `select post_id, title, content, author_id, community_id, pinned, locked, featured, view_num, create_time
 	from post
+	where status = 1
 	ORDER BY create_time
 	DESC 
 	limit ?,?
@@ -96,10 +124,10 @@ func GetPostList(page, size int64) (posts []*models.Post, err error) {
 // GetPostListByKeywords 根据关键词查询帖子列表
 func GetPostListByKeywords(p *models.ParamPostList) (posts []*models.Post, err error) {
 	// 根据帖子标题或者帖子内容模糊查询帖子列表
-	sqlStr := `select post_id, title, content, author_id, community_id, create_time
+	sqlStr := `select post_id, title, content, author_id, community_id, pinned, locked, featured, view_num, create_time
 	from post
-	where title like ?
-	or content like ?
+	where (title like ? or content like ?)
+	and status = 1
 	ORDER BY create_time
 	DESC
 	limit ?,?
@@ -116,11 +144,141 @@ func GetPostListTotalCount(p *models.ParamPostList) (count int64, err error) {
 	// 根据帖子标题或者帖子内容模糊查询帖子列表总数
 	sqlStr := `select count(post_id)
 	from post
-	where title like ?
-	or content like ?
+	where (title like ? or content like ?)
+	and status = 1
 	`
 	// %keyword%
 	p.Search = "%" + p.Search + "%"
 	err = db.Get(&count, sqlStr, p.Search, p.Search)
 	return
 }
+
+// RemovePost 删除帖子：将帖子状态置为0，帖子数据仍保留在数据库中
+func RemovePost(postID uint64) (err error) {
+	sqlStr := `update post set status = 0 where post_id = ? and status != 0`
+	_, err = db.Exec(sqlStr, postID)
+	return
+}
+
+// GetPostCommunityID 查询帖子所属的社区，已删除的帖子同样可以查询
+func GetPostCommunityID(postID uint64) (communityID uint64, err error) {
+	sqlStr := `select community_id from post where post_id = ?`
+	err = db.Get(&communityID, sqlStr, postID)
+	return
+}
+
+// GetPostByIDIncludeDraft 根据post_id查询未删除的帖子，包括草稿和定时发布的帖子
+func GetPostByIDIncludeDraft(postID uint64) (post *models.Post, err error) {
+	post = new(models.Post)
+	sqlStr := `select post_id, title, content, author_id, community_id, pinned, locked, featured, status, publish_at, create_time, update_time
+	from post
+	where post_id = ? and status != 0`
+	err = db.Get(post, sqlStr, postID)
+	return
+}
+
+// GetDraftList 分页查询用户的草稿和定时发布的帖子
+func GetDraftList(authorID uint64, page, size int64) (posts []*models.Post, err error) {
+	sqlStr := `select post_id, title, content, author_id, community_id, pinned, locked, featured, status, publish_at, create_time, update_time
+	from post
+	where author_id = ? and status in (2, 3)
+	order by update_time desc
+	limit ?,?`
+	posts = make([]*models.Post, 0)
+	err = db.Select(&posts, sqlStr, authorID, (page-1)*size, size)
+	return
+}
+
+// GetDuePosts 查询已到发布时间的定时发布帖子
+func GetDuePosts(now time.Time, limit int) (posts []*models.Post, err error) {
+	sqlStr := `select post_id, title, content, author_id, community_id, pinned, locked, featured, status, publish_at
+	from post
+	where status = 3 and publish_at <= ?
+	order by publish_at
+	limit ?`
+	err = db.Select(&posts, sqlStr, now, limit)
+	return
+}
+
+// PublishPost 将草稿或定时发布的帖子改为已发布，帖子状态不是fromStatus时返回false
+// 依靠条件更新保证多个实例同时发布同一帖子时只有一个成功
+func PublishPost(postID uint64, fromStatus int32) (ok bool, err error) {
+	sqlStr := `update post set status = 1, publish_at = now() where post_id = ? and status = ?`
+	ret, err := db.Exec(sqlStr, postID, fromStatus)
+	if err != nil {
+		return
+	}
+	n, err := ret.RowsAffected()
+	return n > 0, err
+}
+
+// SchedulePost 设置草稿的定时发布时间
+func SchedulePost(postID uint64, publishAt time.Time) (err error) {
+	sqlStr := `update post set status = 3, publish_at = ? where post_id = ? and status in (2, 3)`
+	_, err = db.Exec(sqlStr, publishAt, postID)
+	return
+}
+
+// SetPostFlags 设置社区中帖子的置顶、锁定、精华状态，为空的字段不修改，置顶帖子数量达到maxPinned时ok为false
+// 置顶时先锁定社区记录再统计置顶数量，同一社区的并发置顶请求依次执行，不会超过上限
+func SetPostFlags(communityID, postID uint64, p *models.ParamPostFlags, maxPinned int64) (ok bool, err error) {
+	sets := make([]string, 0, 3)
+	args := make([]interface{}, 0, 5)
+	if p.Pinned != nil {
+		sets = append(sets, "pinned = ?")
+		args = append(args, *p.Pinned)
+	}
+	if p.Locked != nil {
+		sets = append(sets, "locked = ?")
+		args = append(args, *p.Locked)
+	}
+	if p.Featured != nil {
+		sets = append(sets, "featured = ?")
+		args = append(args, *p.Featured)
+	}
+	if len(sets) == 0 {
+		return true, nil
+	}
+	tx, err := db.Beginx()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if p.Pinned != nil && *p.Pinned {
+		var id uint64
+		if err = tx.Get(&id, `select community_id from community where community_id = ? for update`, communityID); err != nil {
+			return
+		}
+		var count int64
+		sqlStr := `select count(post_id) from post where community_id = ? and pinned = 1 and status = 1 and post_id != ?`
+		if err = tx.Get(&count, sqlStr, communityID, postID); err != nil {
+			return
+		}
+		if count >= maxPinned {
+			return false, tx.Rollback()
+		}
+	}
+	sqlStr := `update post set ` + strings.Join(sets, ", ") + ` where post_id = ? and community_id = ? and status = 1`
+	if _, err = tx.Exec(sqlStr, append(args, postID, communityID)...); err != nil {
+		return
+	}
+	return true, tx.Commit()
+}
+
+// IsPostLocked 查询已发布的帖子是否被锁定，帖子不存在时返回sql.ErrNoRows
+func IsPostLocked(postID uint64) (locked bool, err error) {
+	sqlStr := `select locked from post where post_id = ? and status = 1`
+	err = db.Get(&locked, sqlStr, postID)
+	return
+}
+
+// AddPostViews 累加帖子的浏览数，不修改帖子的更新时间
+func AddPostViews(postID uint64, delta int64) (err error) {
+	sqlStr := `update post set view_num = view_num + ?, update_time = update_time where post_id = ?`
+	_, err = db.Exec(sqlStr, delta, postID)
+	return
+}
@@ -0,0 +1,76 @@
+package mysql
+
+import (
+	"bluebell_backend/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// insertRevision 保存帖子的一个版本
+func insertRevision(tx *sqlx.Tx, postID uint64, revision int64, title, content string, editorID uint64) (err error) {
+	sqlStr := `insert into post_revision(post_id, revision, title, content, editor_id) values(?,?,?,?,?)`
+	_, err = tx.Exec(sqlStr, postID, revision, title, content, editorID)
+	return
+}
+
+// UpdatePost 编辑帖子的标题、内容和所属社区，并保存为新版本，返回新版本号
+// 功能上线前创建的帖子没有版本记录，首次编辑时先将原内容保存为第1个版本
+func UpdatePost(postID uint64, title, content string, communityID, editorID uint64) (revision int64, err error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	// 锁定帖子，保证同一帖子的版本号连续
+	old := new(models.Post)
+	sqlStr := `select post_id, title, content, author_id from post where post_id = ? and status != 0 for update`
+	if err = tx.Get(old, sqlStr, postID); err != nil {
+		return
+	}
+	if err = tx.Get(&revision, `select ifnull(max(revision), 0) from post_revision where post_id = ?`, postID); err != nil {
+		return
+	}
+	if revision == 0 {
+		revision = 1
+		if err = insertRevision(tx, postID, revision, old.Title, old.Content, old.AuthorId); err != nil {
+			return
+		}
+	}
+	// 置顶只在所属社区有效，移动到其他社区时取消置顶(MySQL按顺序赋值，需在修改community_id之前判断)
+	sqlStr = `update post set pinned = if(community_id = ?, pinned, 0), title = ?, content = ?, community_id = ?
+	where post_id = ?`
+	if _, err = tx.Exec(sqlStr, communityID, title, content, communityID, postID); err != nil {
+		return
+	}
+	revision++
+	if err = insertRevision(tx, postID, revision, title, content, editorID); err != nil {
+		return
+	}
+	err = tx.Commit()
+	return
+}
+
+// GetPostRevisions 查询帖子的所有版本，不包含内容，按版本号倒序
+func GetPostRevisions(postID uint64) (revisions []*models.PostRevision, err error) {
+	sqlStr := `select post_id, revision, title, editor_id, create_time
+	from post_revision
+	where post_id = ?
+	order by revision desc`
+	revisions = make([]*models.PostRevision, 0)
+	err = db.Select(&revisions, sqlStr, postID)
+	return
+}
+
+// GetPostRevision 查询帖子的指定版本，版本不存在时返回sql.ErrNoRows
+func GetPostRevision(postID uint64, revision int64) (r *models.PostRevision, err error) {
+	r = new(models.PostRevision)
+	sqlStr := `select post_id, revision, title, content, editor_id, create_time
+	from post_revision
+	where post_id = ? and revision = ?`
+	err = db.Get(r, sqlStr, postID, revision)
+	return
+}
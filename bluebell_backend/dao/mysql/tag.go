@@ -0,0 +1,71 @@
+package mysql
+
+import (
+	"github.com/jmoiron/sqlx"
+)
+
+// insertPostTags 在事务中保存帖子的标签
+func insertPostTags(tx *sqlx.Tx, postID uint64, tags []string) (err error) {
+	sqlStr := `insert into post_tag(post_id, tag_name) values(?,?)`
+	for _, tag := range tags {
+		if _, err = tx.Exec(sqlStr, postID, tag); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// GetPostTags 查询帖子的标签
+func GetPostTags(postID uint64) (tags []string, err error) {
+	sqlStr := `select tag_name from post_tag where post_id = ? order by id`
+	tags = make([]string, 0)
+	err = db.Select(&tags, sqlStr, postID)
+	return
+}
+
+// GetPostTagsByIDs 批量查询帖子的标签，返回post_id到标签列表的映射
+func GetPostTagsByIDs(postIDs []uint64) (tags map[uint64][]string, err error) {
+	tags = make(map[uint64][]string, len(postIDs))
+	if len(postIDs) == 0 {
+		return
+	}
+	query, args, err := sqlx.In(`select post_id, tag_name from post_tag where post_id in (?) order by id`, postIDs)
+	if err != nil {
+		return
+	}
+	var rows []struct {
+		PostID  uint64 `db:"post_id"`
+		TagName string `db:"tag_name"`
+	}
+	if err = db.Select(&rows, db.Rebind(query), args...); err != nil {
+		return
+	}
+	for _, row := range rows {
+		tags[row.PostID] = append(tags[row.PostID], row.TagName)
+	}
+	return
+}
+
+// ReplacePostTags 替换帖子的标签，返回原来的标签
+func ReplacePostTags(postID uint64, tags []string) (oldTags []string, err error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	oldTags = make([]string, 0)
+	if err = tx.Select(&oldTags, `select tag_name from post_tag where post_id = ? order by id for update`, postID); err != nil {
+		return
+	}
+	if _, err = tx.Exec(`delete from post_tag where post_id = ?`, postID); err != nil {
+		return
+	}
+	if err = insertPostTags(tx, postID, tags); err != nil {
+		return
+	}
+	return oldTags, tx.Commit()
+}
@@ -0,0 +1,92 @@
+package mysql
+
+import (
+	"bluebell_backend/models"
+)
+
+// GetUserTOTP 查询用户的两步验证配置
+func GetUserTOTP(userID uint64) (t *models.UserTOTP, err error) {
+	t = new(models.UserTOTP)
+	sqlStr := `select user_id, username, password, totp_secret, totp_enabled, totp_last_counter
+	from user
+	where user_id = ?`
+	err = db.Get(t, sqlStr, userID)
+	return
+}
+
+// SaveTOTPSecret 保存待确认的TOTP密钥，并替换用户的恢复码
+func SaveTOTPSecret(userID uint64, secret string, codeHashes []string) (err error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	sqlStr := `update user set totp_secret = ?, totp_enabled = 0, totp_last_counter = 0 where user_id = ?`
+	if _, err = tx.Exec(sqlStr, secret, userID); err != nil {
+		return
+	}
+	if _, err = tx.Exec(`delete from user_recovery_code where user_id = ?`, userID); err != nil {
+		return
+	}
+	for _, h := range codeHashes {
+		if _, err = tx.Exec(`insert into user_recovery_code(user_id, code_hash) values(?,?)`, userID, h); err != nil {
+			return
+		}
+	}
+	return tx.Commit()
+}
+
+// EnableTOTP 开启两步验证，并记录确认时使用的验证码时间步
+func EnableTOTP(userID, counter uint64) (err error) {
+	sqlStr := `update user set totp_enabled = 1, totp_last_counter = ? where user_id = ? and totp_secret != ''`
+	_, err = db.Exec(sqlStr, counter, userID)
+	return
+}
+
+// DisableTOTP 关闭两步验证，清除密钥和恢复码
+func DisableTOTP(userID uint64) (err error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	sqlStr := `update user set totp_secret = '', totp_enabled = 0, totp_last_counter = 0 where user_id = ?`
+	if _, err = tx.Exec(sqlStr, userID); err != nil {
+		return
+	}
+	if _, err = tx.Exec(`delete from user_recovery_code where user_id = ?`, userID); err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+// UseTOTPCounter 记录已使用的验证码时间步，时间步不大于上次记录时返回false(验证码重放)
+func UseTOTPCounter(userID, counter uint64) (ok bool, err error) {
+	sqlStr := `update user set totp_last_counter = ? where user_id = ? and totp_last_counter < ?`
+	ret, err := db.Exec(sqlStr, counter, userID, counter)
+	if err != nil {
+		return
+	}
+	n, err := ret.RowsAffected()
+	return n > 0, err
+}
+
+// UseRecoveryCode 使用恢复码，每个恢复码只能使用一次
+func UseRecoveryCode(userID uint64, codeHash string) (ok bool, err error) {
+	sqlStr := `update user_recovery_code set used_time = now()
+	where user_id = ? and code_hash = ? and used_time is null`
+	ret, err := db.Exec(sqlStr, userID, codeHash)
+	if err != nil {
+		return
+	}
+	n, err := ret.RowsAffected()
+	return n > 0, err
+}
@@ -2,11 +2,13 @@ package mysql
 
 import (
 	"bluebell_backend/models"
+	"bluebell_backend/pkg/password"
 	"bluebell_backend/pkg/snowflake"
-	"crypto/md5"
 	"database/sql"
-	"encoding/hex"
 	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
 )
 
 /**
@@ -14,15 +16,6 @@ import (
  * 供logic层根据业务需求调用
  **/
 
-const secret = "huchao.vip"
-
-// Md5算法加密用户密码
-func encryptPassword(data []byte) (result string) {
-	h := md5.New()
-	h.Write([]byte(secret))
-	return hex.EncodeToString(h.Sum(data))
-}
-
 // 注册业务：检查指定username的用户是否存在
 func CheckUserExist(username string) (error error) {
 	sqlStr := `select count(user_id) from user where username = ?`
@@ -39,10 +32,13 @@ func CheckUserExist(username string) (error error) {
 // 注册业务：向数据库中插入一条新的用户
 func InsertUser(user models.User) (error error) {
 	// 加密密码
-	user.Password = encryptPassword([]byte(user.Password))
+	hashed, err := password.Hash(user.Password)
+	if err != nil {
+		return err
+	}
 	// 执行sql插入数据
 	sqlstr := `insert into user(user_id,username,password,email,gender) values(?,?,?,?,?)`
-	_, err := db.Exec(sqlstr, user.UserID, user.UserName, user.Password, user.Email, user.Gender)
+	_, err = db.Exec(sqlstr, user.UserID, user.UserName, hashed, user.Email, user.Gender)
 	return err
 }
 
@@ -63,17 +59,20 @@ func Register(user *models.User) (err error) {
 		return ErrorGenIDFailed
 	}
 	// 生成加密密码
-	password := encryptPassword([]byte(user.Password))
+	hashed, err := password.Hash(user.Password)
+	if err != nil {
+		return err
+	}
 	// 把用户插入数据库
 	sqlStr = "insert into user(user_id, username, password) values (?,?,?)"
-	_, err = db.Exec(sqlStr, userID, user.UserName, password)
+	_, err = db.Exec(sqlStr, userID, user.UserName, hashed)
 	return
 }
 
 // 登录业务：判断用户是否存在以及密码是否正确
 func Login(user *models.User) (err error) {
 	originPassword := user.Password // 记录下原始密码
-	sqlStr := "select user_id, username, password from user where username = ?"
+	sqlStr := "select user_id, username, password, role, totp_enabled from user where username = ?"
 	err = db.Get(user, sqlStr, user.UserName)
 	// 查询数据库出错
 	if err != nil && err != sql.ErrNoRows {
@@ -83,18 +82,172 @@ func Login(user *models.User) (err error) {
 	if err == sql.ErrNoRows {
 		return errors.New(ErrorUserNotExit)
 	}
-	// 生成加密密码与查询到的密码比较
-	password := encryptPassword([]byte(originPassword))
-	if user.Password != password {
+	// 校验密码
+	ok, rehash, err := password.Verify(user.Password, originPassword)
+	if err != nil {
+		return err
+	}
+	if !ok {
 		return errors.New(ErrorPasswordWrong)
 	}
+	// 旧算法或旧参数生成的哈希，登录成功后使用当前算法重新哈希
+	if rehash {
+		if err := UpdateUserPassword(user.UserID, originPassword); err != nil {
+			zap.L().Warn("rehash password failed", zap.Uint64("user_id", user.UserID), zap.Error(err))
+		}
+	}
 	return nil
 }
 
+// UpdateUserPassword 使用当前算法哈希并更新用户密码
+func UpdateUserPassword(userID uint64, plain string) (err error) {
+	hashed, err := password.Hash(plain)
+	if err != nil {
+		return
+	}
+	sqlStr := `update user set password = ? where user_id = ?`
+	_, err = db.Exec(sqlStr, hashed, userID)
+	return
+}
+
 // GetUserByID 根据user_id查询作者信息
 func GetUserByID(id uint64) (user *models.User, err error) {
 	user = new(models.User)
-	sqlStr := `select user_id, username from user where user_id = ?`
+	sqlStr := `select user_id, username, ifnull(email, '') as email, email_verified, role from user where user_id = ?`
 	err = db.Get(user, sqlStr, id)
 	return
 }
+
+// GetUserNamesByIDs 批量查询用户名，返回user_id到用户名的映射
+func GetUserNamesByIDs(ids []uint64) (names map[uint64]string, err error) {
+	names = make(map[uint64]string, len(ids))
+	if len(ids) == 0 {
+		return
+	}
+	query, args, err := sqlx.In(`select user_id, username from user where user_id in (?)`, ids)
+	if err != nil {
+		return
+	}
+	var users []*models.User
+	if err = db.Select(&users, db.Rebind(query), args...); err != nil {
+		return
+	}
+	for _, user := range users {
+		names[user.UserID] = user.UserName
+	}
+	return
+}
+
+// GetUserIDsByNames 根据用户名批量查询用户ID，返回用户名到user_id的映射
+func GetUserIDsByNames(names []string) (ids map[string]uint64, err error) {
+	ids = make(map[string]uint64, len(names))
+	if len(names) == 0 {
+		return
+	}
+	query, args, err := sqlx.In(`select user_id, username from user where username in (?)`, names)
+	if err != nil {
+		return
+	}
+	var users []*models.User
+	if err = db.Select(&users, db.Rebind(query), args...); err != nil {
+		return
+	}
+	for _, user := range users {
+		ids[user.UserName] = user.UserID
+	}
+	return
+}
+
+// SetEmailVerified 将用户邮箱标记为已验证，用户邮箱已不是email时ok为false
+func SetEmailVerified(userID uint64, email string) (ok bool, err error) {
+	sqlStr := `update user set email_verified = 1 where user_id = ? and email = ?`
+	ret, err := db.Exec(sqlStr, userID, email)
+	if err != nil {
+		return
+	}
+	n, err := ret.RowsAffected()
+	return n > 0, err
+}
+
+// IsEmailVerified 查询用户邮箱是否已验证
+func IsEmailVerified(userID uint64) (verified bool, err error) {
+	sqlStr := `select email_verified from user where user_id = ?`
+	err = db.Get(&verified, sqlStr, userID)
+	return
+}
+
+// GetVerifiedUsersByEmail 根据邮箱查询已验证该邮箱的用户，邮箱不唯一，可能对应多个账号
+func GetVerifiedUsersByEmail(email string) (users []*models.User, err error) {
+	sqlStr := `select user_id, username, ifnull(email, '') as email, email_verified from user where email = ? and email_verified = 1`
+	err = db.Select(&users, sqlStr, email)
+	return
+}
+
+// CheckUserPassword 校验用户当前密码是否正确
+func CheckUserPassword(userID uint64, plain string) (err error) {
+	var hashed string
+	sqlStr := `select password from user where user_id = ?`
+	if err = db.Get(&hashed, sqlStr, userID); err != nil {
+		return
+	}
+	ok, _, err := password.Verify(hashed, plain)
+	if err != nil {
+		return
+	}
+	if !ok {
+		return errors.New(ErrorPasswordWrong)
+	}
+	return nil
+}
+
+// GetUserProfile 查询用户公开资料
+func GetUserProfile(userID uint64) (profile *models.UserProfile, err error) {
+	profile = new(models.UserProfile)
+	sqlStr := `select user_id, username, display_name, bio, avatar_url, gender, create_time
+	from user
+	where user_id = ?`
+	err = db.Get(profile, sqlStr, userID)
+	return
+}
+
+// GetMyProfile 查询当前用户的资料
+func GetMyProfile(userID uint64) (profile *models.ApiMyProfile, err error) {
+	profile = new(models.ApiMyProfile)
+	sqlStr := `select user_id, username, display_name, bio, avatar_url, gender, create_time,
+	ifnull(email, '') as email, email_verified
+	from user
+	where user_id = ?`
+	err = db.Get(profile, sqlStr, userID)
+	return
+}
+
+// UpdateUserProfile 更新用户资料，邮箱为空时不修改邮箱，邮箱变更时需要重新验证
+func UpdateUserProfile(userID uint64, p *models.ParamUpdateProfile) (err error) {
+	sqlStr := `update user
+	set email_verified = if(? = '' or ifnull(email, '') = ?, email_verified, 0),
+	email = if(? = '', email, ?), gender = ?, display_name = ?, bio = ?, avatar_url = ?
+	where user_id = ?`
+	_, err = db.Exec(sqlStr, p.Email, p.Email, p.Email, p.Email, p.Gender, p.DisplayName, p.Bio, p.AvatarURL, userID)
+	return
+}
+
+// GetUserPostIDs 查询用户发布的所有帖子ID
+func GetUserPostIDs(userID uint64) (ids []string, err error) {
+	sqlStr := `select post_id from post where author_id = ? and status = 1`
+	err = db.Select(&ids, sqlStr, userID)
+	return
+}
+
+// GetUserCommentCount 查询用户发表的评论数
+func GetUserCommentCount(userID uint64) (count int64, err error) {
+	sqlStr := `select count(comment_id) from comment where author_id = ? and status = 1`
+	err = db.Get(&count, sqlStr, userID)
+	return
+}
+
+// SetUserRole 设置用户角色
+func SetUserRole(userID uint64, role string) (err error) {
+	sqlStr := `update user set role = ? where user_id = ?`
+	_, err = db.Exec(sqlStr, role, userID)
+	return
+}
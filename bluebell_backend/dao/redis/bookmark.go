@@ -0,0 +1,99 @@
+package redis
+
+import (
+	"bluebell_backend/models"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+/*
+收藏帖子
+	* MySQL bookmark表保存用户收藏的帖子，redis中每个用户一个ZSet [bluebell:bookmark:user_id, (post_id, 收藏时间)] 作为缓存
+	* 缓存不存在时从MySQL重建，缓存的过期时间为bookmarkCacheExpiration，每次读取时续期
+	* 收藏/取消收藏时删除缓存并将用户的收藏版本号加1；重建时只有版本号与查询MySQL前相同才写入，
+	  避免重建期间的收藏变化被旧数据覆盖
+*/
+
+const bookmarkCacheExpiration = 24 * time.Hour
+
+// setBookmarksScript 版本号未变化时重建用户的收藏缓存
+// KEYS[1] 用户收藏ZSet KEYS[2] 用户收藏版本号 ARGV[1] 查询MySQL前的版本号 ARGV[2] 缓存过期时间(秒) ARGV[3...] 收藏时间, post_id
+var setBookmarksScript = redis.NewScript(`
+if (redis.call('GET', KEYS[2]) or '0') ~= ARGV[1] then
+	return 0
+end
+redis.call('DEL', KEYS[1])
+for i = 3, #ARGV, 2 do
+	redis.call('ZADD', KEYS[1], ARGV[i], ARGV[i + 1])
+end
+redis.call('EXPIRE', KEYS[1], ARGV[2])
+return 1
+`)
+
+// InvalidateBookmarks 收藏变化后删除用户的收藏缓存，并使正在进行的重建失效
+func InvalidateBookmarks(userID uint64) (err error) {
+	uid := strconv.FormatUint(userID, 10)
+	pipeline := client.TxPipeline()
+	pipeline.Incr(KeyBookmarkVersionPrefix + uid)
+	pipeline.Expire(KeyBookmarkVersionPrefix+uid, bookmarkCacheExpiration)
+	pipeline.Del(KeyBookmarkZSetPrefix + uid)
+	_, err = pipeline.Exec()
+	return
+}
+
+// GetBookmarkVersion 查询用户收藏的版本号，重建缓存前调用
+func GetBookmarkVersion(userID uint64) (string, error) {
+	version, err := client.Get(KeyBookmarkVersionPrefix + strconv.FormatUint(userID, 10)).Result()
+	if err == redis.Nil {
+		return "0", nil
+	}
+	return version, err
+}
+
+// SetBookmarks 使用MySQL中的收藏记录重建用户的收藏缓存，version为查询MySQL前的版本号
+func SetBookmarks(userID uint64, version string, bookmarks []*models.Bookmark) (err error) {
+	if len(bookmarks) == 0 {
+		return
+	}
+	uid := strconv.FormatUint(userID, 10)
+	args := make([]interface{}, 0, 2*len(bookmarks)+2)
+	args = append(args, version, int64(bookmarkCacheExpiration/time.Second))
+	for _, b := range bookmarks {
+		args = append(args, b.CreateTime.Unix(), b.PostID)
+	}
+	return setBookmarksScript.Run(client, []string{KeyBookmarkZSetPrefix + uid, KeyBookmarkVersionPrefix + uid}, args...).Err()
+}
+
+// GetBookmarkIDs 按收藏时间降序分页查询用户收藏的帖子ids及总数，cached为false表示缓存不存在
+func GetBookmarkIDs(userID uint64, page, size int64) (ids []string, total int64, cached bool, err error) {
+	key := KeyBookmarkZSetPrefix + strconv.FormatUint(userID, 10)
+	start := (page - 1) * size
+	pipeline := client.TxPipeline()
+	expire := pipeline.Expire(key, bookmarkCacheExpiration) // key不存在时返回false
+	card := pipeline.ZCard(key)
+	members := pipeline.ZRevRange(key, start, start+size-1)
+	if _, err = pipeline.Exec(); err != nil {
+		return
+	}
+	if !expire.Val() {
+		return nil, 0, false, nil
+	}
+	return members.Val(), card.Val(), true, nil
+}
+
+// IsBookmarked 查询缓存中用户是否收藏了帖子，cached为false表示缓存不存在
+func IsBookmarked(userID, postID uint64) (bookmarked, cached bool, err error) {
+	key := KeyBookmarkZSetPrefix + strconv.FormatUint(userID, 10)
+	pipeline := client.TxPipeline()
+	exists := pipeline.Exists(key)
+	score := pipeline.ZScore(key, strconv.FormatUint(postID, 10))
+	if _, err = pipeline.Exec(); err != nil && err != redis.Nil {
+		return
+	}
+	if exists.Val() == 0 {
+		return false, false, nil
+	}
+	return score.Err() == nil, true, nil
+}
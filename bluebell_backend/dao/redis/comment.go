@@ -0,0 +1,155 @@
+package redis
+
+import (
+	"bluebell_backend/models"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// CreateComment 将一级评论加入帖子的评论得分ZSet和Wilson得分ZSet，初始得分为发布时间，Wilson得分为0
+func CreateComment(postID, commentID uint64, t time.Time) (err error) {
+	pid := strconv.FormatUint(postID, 10)
+	pipeline := client.TxPipeline()
+	pipeline.ZAdd(KeyCommentScoreZSetPrefix+pid, redis.Z{
+		Score:  float64(t.Unix()),
+		Member: commentID,
+	})
+	pipeline.ZAdd(KeyCommentBestZSetPrefix+pid, redis.Z{
+		Score:  0,
+		Member: commentID,
+	})
+	_, err = pipeline.Exec()
+	return
+}
+
+// RemoveComment 将评论移出帖子的评论得分ZSet
+func RemoveComment(postID, commentID uint64) (err error) {
+	pid := strconv.FormatUint(postID, 10)
+	pipeline := client.TxPipeline()
+	pipeline.ZRem(KeyCommentScoreZSetPrefix+pid, commentID)
+	pipeline.ZRem(KeyCommentBestZSetPrefix+pid, commentID)
+	_, err = pipeline.Exec()
+	return
+}
+
+// indexCommentsScript 将不在得分ZSet中的一级评论加入得分ZSet和Wilson得分ZSet，得分按发布时间和已有的投票计算
+// 已在ZSet中的评论不修改，与投票在同一脚本中读取票数，避免补充期间的投票丢失
+// KEYS[1] 帖子的评论票数Hash KEYS[2] 一级评论得分ZSet KEYS[3] 一级评论Wilson得分ZSet
+// ARGV[1] 每票的分数 ARGV[2] Wilson得分的z值 ARGV[3...] comment_id, 发布时间
+var indexCommentsScript = redis.NewScript(`
+local z = tonumber(ARGV[2])
+for i = 3, #ARGV, 2 do
+	local id = ARGV[i]
+	if not redis.call('ZSCORE', KEYS[2], id) then
+		local ups = tonumber(redis.call('HGET', KEYS[1], id .. ':up') or '0')
+		local downs = tonumber(redis.call('HGET', KEYS[1], id .. ':down') or '0')
+		redis.call('ZADD', KEYS[2], tonumber(ARGV[i + 1]) + (ups - downs) * tonumber(ARGV[1]), id)
+		local n = ups + downs
+		local score = 0
+		if n > 0 then
+			local p = ups / n
+			score = (p + z * z / (2 * n) - z * math.sqrt((p * (1 - p) + z * z / (4 * n)) / n)) / (1 + z * z / n)
+		end
+		redis.call('ZADD', KEYS[3], score, id)
+	end
+end
+return 0
+`)
+
+// indexCommentsBatchSize 每次执行indexCommentsScript加入的评论数
+const indexCommentsBatchSize = 500
+
+// IsCommentIndexed 查询帖子已有的一级评论是否均已加入得分ZSet
+func IsCommentIndexed(postID uint64) (bool, error) {
+	n, err := client.Exists(KeyCommentIndexedPrefix + strconv.FormatUint(postID, 10)).Result()
+	return n > 0, err
+}
+
+// IndexComments 将MySQL中的一级评论补充到帖子的得分ZSet和Wilson得分ZSet，完成后标记帖子
+func IndexComments(postID uint64, comments []*models.Comment) (err error) {
+	pid := strconv.FormatUint(postID, 10)
+	keys := []string{KeyCommentVoteHashPrefix + pid, KeyCommentScoreZSetPrefix + pid, KeyCommentBestZSetPrefix + pid}
+	for start := 0; start < len(comments); start += indexCommentsBatchSize {
+		end := start + indexCommentsBatchSize
+		if end > len(comments) {
+			end = len(comments)
+		}
+		args := make([]interface{}, 0, 2*(end-start)+2)
+		args = append(args, VoteScore, WilsonZ)
+		for _, c := range comments[start:end] {
+			args = append(args, c.CommentID, c.CreateTime.Unix())
+		}
+		if err = indexCommentsScript.Run(client, keys, args...).Err(); err != nil {
+			return
+		}
+	}
+	return client.Set(KeyCommentIndexedPrefix+pid, 1, 0).Err()
+}
+
+// GetCommentIDsInOrder 按得分(score)或Wilson得分(best)降序分页查询帖子的一级评论ids及总数
+func GetCommentIDsInOrder(postID uint64, order string, page, size int64) (ids []string, total int64, err error) {
+	key := KeyCommentScoreZSetPrefix + strconv.FormatUint(postID, 10)
+	if order == models.OrderBest {
+		key = KeyCommentBestZSetPrefix + strconv.FormatUint(postID, 10)
+	}
+	start := (page - 1) * size
+	pipeline := client.Pipeline()
+	card := pipeline.ZCard(key)
+	members := pipeline.ZRevRange(key, start, start+size-1)
+	if _, err = pipeline.Exec(); err != nil {
+		return
+	}
+	return members.Val(), card.Val(), nil
+}
+
+// postCommentNumScript 修改帖子Hash中的评论数并同步到评论数ZSet，帖子已从redis中删除时不做修改
+// KEYS[1] 帖子Hash KEYS[2] 帖子评论数ZSet ARGV[1] post_id ARGV[2] incr:增加 set:设置 ARGV[3] 数量
+var postCommentNumScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+local n = tonumber(ARGV[3])
+if ARGV[2] == 'incr' then
+	n = redis.call('HINCRBY', KEYS[1], 'comments', n)
+end
+if n < 0 then
+	n = 0
+end
+redis.call('HSET', KEYS[1], 'comments', n)
+redis.call('ZADD', KEYS[2], n, ARGV[1])
+return n
+`)
+
+// IncrPostCommentNum 发表/删除评论时增加/减少帖子的评论数
+func IncrPostCommentNum(postID uint64, delta int64) error {
+	pid := strconv.FormatUint(postID, 10)
+	return postCommentNumScript.Run(client, []string{KeyPostInfoHashPrefix + pid, KeyPostCommentZSet},
+		pid, "incr", delta).Err()
+}
+
+// SetPostCommentNum 使用MySQL中统计的评论数校正redis中帖子的评论数
+func SetPostCommentNum(postID uint64, n int64) error {
+	pid := strconv.FormatUint(postID, 10)
+	return postCommentNumScript.Run(client, []string{KeyPostInfoHashPrefix + pid, KeyPostCommentZSet},
+		pid, "set", n).Err()
+}
+
+// GetPostCommentNums 批量查询帖子的评论数
+func GetPostCommentNums(ids []string) (data []int64, err error) {
+	pipeline := client.Pipeline()
+	cmds := make([]*redis.StringCmd, 0, len(ids))
+	for _, id := range ids {
+		cmds = append(cmds, pipeline.HGet(KeyPostInfoHashPrefix+id, "comments"))
+	}
+	if _, err = pipeline.Exec(); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	data = make([]int64, 0, len(ids))
+	for _, cmd := range cmds {
+		n, _ := cmd.Int64() // 帖子不在redis中时为0
+		data = append(data, n)
+	}
+	return data, nil
+}
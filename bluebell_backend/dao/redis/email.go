@@ -0,0 +1,63 @@
+package redis
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// oneTimeTokenKey 一次性令牌只保存摘要，Redis数据泄露时令牌也无法直接使用
+func oneTimeTokenKey(prefix, token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return prefix + hex.EncodeToString(sum[:])
+}
+
+// saveOneTimeToken 保存一次性令牌 [prefix+sha256(token), value]
+func saveOneTimeToken(prefix, token, value string, ttl time.Duration) error {
+	return client.Set(oneTimeTokenKey(prefix, token), value, ttl).Err()
+}
+
+// takeOneTimeToken 取出并删除一次性令牌，令牌不存在时返回redis.Nil
+func takeOneTimeToken(prefix, token string) (value string, err error) {
+	key := oneTimeTokenKey(prefix, token)
+	pipeline := client.TxPipeline()
+	get := pipeline.Get(key)
+	pipeline.Del(key)
+	if _, err = pipeline.Exec(); err != nil {
+		return
+	}
+	return get.Val(), nil
+}
+
+// SaveEmailVerifyToken 保存邮箱验证令牌 [user_id:email]，令牌只对发送时的邮箱有效
+func SaveEmailVerifyToken(token string, userID uint64, email string, ttl time.Duration) error {
+	value := strconv.FormatUint(userID, 10) + ":" + email
+	return saveOneTimeToken(KeyEmailVerifyTokenPrefix, token, value, ttl)
+}
+
+// TakeEmailVerifyToken 使用邮箱验证令牌，返回令牌对应的用户和邮箱，令牌只能使用一次
+func TakeEmailVerifyToken(token string) (userID uint64, email string, err error) {
+	value, err := takeOneTimeToken(KeyEmailVerifyTokenPrefix, token)
+	if err != nil {
+		return
+	}
+	id, email, _ := strings.Cut(value, ":")
+	userID, err = strconv.ParseUint(id, 10, 64)
+	return
+}
+
+// SavePasswordResetToken 保存重置密码令牌
+func SavePasswordResetToken(token string, userID uint64, ttl time.Duration) error {
+	return saveOneTimeToken(KeyPasswordResetTokenPrefix, token, strconv.FormatUint(userID, 10), ttl)
+}
+
+// TakePasswordResetToken 使用重置密码令牌，令牌只能使用一次
+func TakePasswordResetToken(token string) (uint64, error) {
+	value, err := takeOneTimeToken(KeyPasswordResetTokenPrefix, token)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
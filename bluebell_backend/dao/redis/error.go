@@ -6,4 +6,12 @@ var (
 	ErrorVoteTimeExpire = errors.New("超过投票时间")
 	ErrorVoted          = errors.New("已投票")
 	ErrVoteRepeated     = errors.New("不允许重复投票")
+
+	ErrorPollNotExist      = errors.New("投票不存在")
+	ErrorPollClosed        = errors.New("投票已截止")
+	ErrorPollVoted         = errors.New("已参与过该投票")
+	ErrorInvalidPollOption = errors.New("无效的投票选项")
+
+	ErrorTokenFamilyNotFound = errors.New("Token已失效")
+	ErrorRefreshTokenReused  = errors.New("refresh_token重复使用")
 )
@@ -7,6 +7,39 @@ const (
 	KeyPostScoreZSet      = "bluebell:post:score" // 存储帖子得分信息 ZSet
 	//KeyPostVotedUpSetPrefix   = "bluebell:post:voted:down:"
 	//KeyPostVotedDownSetPrefix = "bluebell:post:voted:up:"
-	KeyPostVotedZSetPrefix    = "bluebell:post:voted:" // 存储某帖子投票信息 ZSet;后跟参数是post_id
-	KeyCommunityPostSetPrefix = "bluebell:community:"  // 存储某社区下所有帖子ID Set;后跟参数community_id
+	KeyPostVotedZSetPrefix       = "bluebell:post:voted:"            // 存储某帖子投票信息 ZSet;后跟参数是post_id
+	KeyCommentBestZSetPrefix     = "bluebell:comment:best:"          // 存储某帖子一级评论的Wilson得分 ZSet;后跟参数post_id
+	KeyCommentVoteHashPrefix     = "bluebell:comment:vote:"          // 存储某帖子中评论的赞成票/反对票数量 Hash;字段为comment_id:up/down;后跟参数post_id
+	KeyCommentVotedHashPrefix    = "bluebell:comment:voted:"         // 存储某用户对某帖子中评论的投票 Hash;后跟参数post_id:user_id
+	KeyCommentScoreZSetPrefix    = "bluebell:comment:score:"         // 存储某帖子一级评论的得分 ZSet;后跟参数post_id
+	KeyCommentIndexedPrefix      = "bluebell:comment:indexed:"       // 标记某帖子已有的一级评论均已加入得分ZSet String;后跟参数post_id
+	KeyCommunityPostSetPrefix    = "bluebell:community:"             // 存储某社区下所有帖子ID Set;后跟参数community_id
+	KeyTagPostSetPrefix          = "bluebell:tag:post:"              // 存储某标签下所有帖子ID Set;后跟参数tag
+	KeyPostViewHLLPrefix         = "bluebell:post:view:"             // 存储某帖子当天的访客 HyperLogLog;后跟参数post_id:日期
+	KeyPostViewDirtySetPrefix    = "bluebell:post:view:dirty:"       // 存储当天有新浏览的帖子ID Set;后跟参数日期
+	KeyPostViewFlushedHashPrefix = "bluebell:post:view:flushed:"     // 存储当天已同步到MySQL的浏览数 Hash;后跟参数日期
+	KeyPostViewSaltPrefix        = "bluebell:post:view:salt:"        // 存储当天计算访客IP哈希值的随机密钥 String;后跟参数日期
+	KeyPostCommentZSet           = "bluebell:post:comments"          // 存储帖子评论数 ZSet
+	KeyPostPinnedZSet            = "bluebell:post:pinned"            // 存储所有置顶帖子 ZSet;分数为置顶时间
+	KeyCommunityPinnedZSetPrefix = "bluebell:community:pinned:"      // 存储某社区的置顶帖子 ZSet;后跟参数community_id
+	KeyPollInfoHashPrefix        = "bluebell:poll:"                  // 存储投票的选项数、是否多选、截止时间 Hash;后跟参数post_id
+	KeyPollVotedHashPrefix       = "bluebell:poll:voted:"            // 存储用户选择的选项 Hash;后跟参数post_id
+	KeyPollCountHashPrefix       = "bluebell:poll:count:"            // 存储每个选项的票数 Hash;后跟参数post_id
+	KeyPollCloserLock            = "bluebell:lock:poll:closer"       // 截止投票任务的分布式锁 String
+	KeyBookmarkZSetPrefix        = "bluebell:bookmark:"              // 存储某用户收藏的帖子 ZSet;分数为收藏时间;后跟参数user_id
+	KeyBookmarkVersionPrefix     = "bluebell:bookmark:version:"      // 存储某用户收藏的版本号 String;收藏变化时加1;后跟参数user_id
+	KeyTagPopularZSet            = "bluebell:tag:popular"            // 存储标签的帖子数量 ZSet
+	KeyNotificationUnreadPrefix  = "bluebell:notification:unread:"   // 存储某用户的未读通知数量 String;后跟参数user_id
+	KeySessionZSetPrefix         = "bluebell:session:"               // 存储某用户已登录设备的Access Token ZSet;后跟参数user_id
+	KeySessionFamilyHashPrefix   = "bluebell:session:family:"        // 存储某用户已登录设备的Access Token所属的Token家族 Hash;后跟参数user_id
+	KeyTokenFamilyPrefix         = "bluebell:token:family:"          // 存储Token家族当前有效的Token Hash;后跟参数family_id
+	KeyUserTokenFamilyZSetPrefix = "bluebell:token:family:user:"     // 存储某用户的Token家族 ZSet;后跟参数user_id
+	KeyTokenRevokedPrefix        = "bluebell:token:revoked:"         // 已吊销的Token String;后跟参数jti
+	KeyEmailVerifyTokenPrefix    = "bluebell:email:verify:"          // 邮箱验证令牌 String;后跟参数sha256(token)
+	KeyPasswordResetTokenPrefix  = "bluebell:password:reset:"        // 重置密码令牌 String;后跟参数sha256(token)
+	KeyTwoFactorChallengePrefix  = "bluebell:2fa:challenge:"         // 两步验证登录临时凭证 Hash;后跟参数sha256(token)
+	KeyPostPublisherLock         = "bluebell:lock:post:publisher"    // 定时发布帖子任务的分布式锁 String
+	KeyPostViewFlushLock         = "bluebell:lock:post:view"         // 同步帖子浏览数任务的分布式锁 String
+	KeyCommentReconcileLock      = "bluebell:lock:comment:reconcile" // 校正帖子评论数任务的分布式锁 String
+	KeyAttachmentGCLock          = "bluebell:lock:attachment:gc"     // 清理未关联附件任务的分布式锁 String
 )
@@ -0,0 +1,25 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// unlockScript 只有持有锁的实例才能释放锁，避免锁过期后误删其他实例的锁
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// TryLock 尝试获取分布式锁，token用于标识锁的持有者，ttl到期后锁自动释放
+func TryLock(key, token string, ttl time.Duration) (bool, error) {
+	return client.SetNX(key, token, ttl).Result()
+}
+
+// Unlock 释放分布式锁
+func Unlock(key, token string) error {
+	return unlockScript.Run(client, []string{key}, token).Err()
+}
@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// 未读通知数量缓存的过期时间，缓存不存在时从MySQL统计
+const notificationUnreadExpiration = 24 * time.Hour
+
+// incrUnreadScript 缓存存在时修改未读通知数量，结果不小于0
+// KEYS[1] 未读通知数量 ARGV[1] 增加的数量 ARGV[2] 过期时间(秒)
+var incrUnreadScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+local n = redis.call('INCRBY', KEYS[1], ARGV[1])
+if n < 0 then
+	redis.call('SET', KEYS[1], 0)
+end
+redis.call('EXPIRE', KEYS[1], ARGV[2])
+return 1
+`)
+
+// IncrUnreadNotificationNum 增加(delta>0)或减少(delta<0)用户的未读通知数量
+func IncrUnreadNotificationNum(userID uint64, delta int64) error {
+	key := KeyNotificationUnreadPrefix + strconv.FormatUint(userID, 10)
+	return incrUnreadScript.Run(client, []string{key}, delta, int64(notificationUnreadExpiration/time.Second)).Err()
+}
+
+// SetUnreadNotificationNum 设置用户的未读通知数量
+func SetUnreadNotificationNum(userID uint64, n int64) error {
+	key := KeyNotificationUnreadPrefix + strconv.FormatUint(userID, 10)
+	return client.Set(key, n, notificationUnreadExpiration).Err()
+}
+
+// GetUnreadNotificationNum 查询用户的未读通知数量，cached为false表示缓存不存在
+func GetUnreadNotificationNum(userID uint64) (n int64, cached bool, err error) {
+	key := KeyNotificationUnreadPrefix + strconv.FormatUint(userID, 10)
+	n, err = client.Get(key).Int64()
+	if err == redis.Nil {
+		return 0, false, nil
+	}
+	return n, err == nil, err
+}
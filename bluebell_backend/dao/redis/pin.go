@@ -0,0 +1,98 @@
+package redis
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// PinPost 置顶帖子，帖子同时出现在所有帖子列表和社区帖子列表的顶部
+func PinPost(postID, communityID uint64) (err error) {
+	z := redis.Z{Score: float64(time.Now().Unix()), Member: strconv.FormatUint(postID, 10)}
+	pipeline := client.TxPipeline()
+	pipeline.ZAdd(KeyPostPinnedZSet, z)
+	pipeline.ZAdd(KeyCommunityPinnedZSetPrefix+strconv.FormatUint(communityID, 10), z)
+	_, err = pipeline.Exec()
+	return
+}
+
+// UnpinPost 取消置顶
+func UnpinPost(postID, communityID uint64) (err error) {
+	pid := strconv.FormatUint(postID, 10)
+	pipeline := client.TxPipeline()
+	unpinPost(pipeline, pid, communityID)
+	_, err = pipeline.Exec()
+	return
+}
+
+func unpinPost(pipeline redis.Pipeliner, pid string, communityID uint64) {
+	pipeline.ZRem(KeyPostPinnedZSet, pid)
+	pipeline.ZRem(KeyCommunityPinnedZSetPrefix+strconv.FormatUint(communityID, 10), pid)
+}
+
+// getIDsWithPinned 分页查询帖子ids，置顶帖子(按置顶时间降序)排在最前面，其余帖子按key的分数降序排列
+func getIDsWithPinned(key, pinnedKey string, page, size int64) ([]string, error) {
+	pinned, err := client.ZRevRange(pinnedKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(pinned) == 0 {
+		return getIDsFormKey(key, page, size)
+	}
+
+	start := (page - 1) * size
+	ids := make([]string, 0, size)
+	// 1.当前页包含的置顶帖子
+	if start < int64(len(pinned)) {
+		end := start + size
+		if end > int64(len(pinned)) {
+			end = int64(len(pinned))
+		}
+		ids = append(ids, pinned[start:end]...)
+	}
+	need := size - int64(len(ids))
+	if need == 0 {
+		return ids, nil
+	}
+
+	// 2.其余帖子：offset是在去掉置顶帖子后的序列中的位置，需要换算为在key中的排名
+	offset := start - int64(len(pinned))
+	if offset < 0 {
+		offset = 0
+	}
+	isPinned := make(map[string]bool, len(pinned))
+	ranks := make([]int64, 0, len(pinned))
+	for _, id := range pinned {
+		isPinned[id] = true
+		rank, err := client.ZRevRank(key, id).Result()
+		if err == redis.Nil { // 置顶帖子不在key中
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		ranks = append(ranks, rank)
+	}
+	sort.Slice(ranks, func(i, j int) bool { return ranks[i] < ranks[j] })
+	for _, rank := range ranks {
+		if rank <= offset {
+			offset++
+		}
+	}
+	rest, err := client.ZRevRange(key, offset, offset+need+int64(len(ranks))-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range rest {
+		if isPinned[id] {
+			continue
+		}
+		ids = append(ids, id)
+		if int64(len(ids)) == size {
+			break
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,141 @@
+package redis
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// pollKeepTime 投票截止后redis中的数据保留时间，之后结果从MySQL读取
+const pollKeepTime = time.Second * OneMonthInSeconds
+
+// pollVoteScript 原子地校验投票并记录：投票存在且未截止、选项有效、每个用户只能投一次
+// KEYS[1] 投票信息Hash KEYS[2] 用户选择Hash KEYS[3] 选项票数Hash
+// ARGV[1] user_id ARGV[2] 当前时间戳 ARGV[3...] 选择的选项序号
+var pollVoteScript = redis.NewScript(`
+local info = redis.call('HMGET', KEYS[1], 'options', 'multiple', 'close_at', 'closed')
+if not info[1] then
+	return -1
+end
+local closeAt = tonumber(info[3])
+if info[4] == '1' or (closeAt > 0 and tonumber(ARGV[2]) >= closeAt) then
+	return -2
+end
+if info[2] ~= '1' and #ARGV > 3 then
+	return -3
+end
+local n = tonumber(info[1])
+for i = 3, #ARGV do
+	local option = tonumber(ARGV[i])
+	if not option or option < 0 or option >= n then
+		return -3
+	end
+end
+local chosen = table.concat(ARGV, ',', 3)
+if redis.call('HSETNX', KEYS[2], ARGV[1], chosen) == 0 then
+	return -4
+end
+for i = 3, #ARGV do
+	redis.call('HINCRBY', KEYS[3], ARGV[i], 1)
+end
+return 1
+`)
+
+// CreatePoll 帖子发布时在redis中创建投票，closeAt为零值表示不自动截止
+// 只写入不存在的字段，重复执行不会将已截止的投票重新打开
+func CreatePoll(postID uint64, options int, multiple bool, closeAt time.Time) (err error) {
+	var closeUnix int64
+	if !closeAt.IsZero() {
+		closeUnix = closeAt.Unix()
+	}
+	key := KeyPollInfoHashPrefix + strconv.FormatUint(postID, 10)
+	pipeline := client.TxPipeline()
+	pipeline.HSetNX(key, "options", options)
+	pipeline.HSetNX(key, "multiple", boolToInt(multiple))
+	pipeline.HSetNX(key, "close_at", closeUnix)
+	pipeline.HSetNX(key, "closed", 0)
+	_, err = pipeline.Exec()
+	return
+}
+
+// VoteForPoll 参与投票，每个用户只能投一次
+func VoteForPoll(postID, userID uint64, options []int) (err error) {
+	pid := strconv.FormatUint(postID, 10)
+	args := make([]interface{}, 0, len(options)+2)
+	args = append(args, userID, time.Now().Unix())
+	for _, option := range options {
+		args = append(args, option)
+	}
+	ret, err := pollVoteScript.Run(client, []string{
+		KeyPollInfoHashPrefix + pid,
+		KeyPollVotedHashPrefix + pid,
+		KeyPollCountHashPrefix + pid,
+	}, args...).Int64()
+	if err != nil {
+		return
+	}
+	switch ret {
+	case -1:
+		return ErrorPollNotExist
+	case -2:
+		return ErrorPollClosed
+	case -3:
+		return ErrorInvalidPollOption
+	case -4:
+		return ErrorPollVoted
+	}
+	return nil
+}
+
+// GetPollResult 查询投票中每个选项的票数和参与人数
+func GetPollResult(postID uint64) (votes map[int]int64, voters int64, err error) {
+	pid := strconv.FormatUint(postID, 10)
+	pipeline := client.Pipeline()
+	countCmd := pipeline.HGetAll(KeyPollCountHashPrefix + pid)
+	votersCmd := pipeline.HLen(KeyPollVotedHashPrefix + pid)
+	if _, err = pipeline.Exec(); err != nil {
+		return
+	}
+	votes, err = parsePollCount(countCmd.Val())
+	return votes, votersCmd.Val(), err
+}
+
+// ClosePoll 截止投票：标记为已截止后不再接受投票，返回最终结果，数据保留一段时间后删除
+func ClosePoll(postID uint64) (votes map[int]int64, voters int64, err error) {
+	pid := strconv.FormatUint(postID, 10)
+	keys := []string{KeyPollInfoHashPrefix + pid, KeyPollVotedHashPrefix + pid, KeyPollCountHashPrefix + pid}
+	pipeline := client.TxPipeline()
+	pipeline.HSet(keys[0], "closed", 1)
+	countCmd := pipeline.HGetAll(keys[2])
+	votersCmd := pipeline.HLen(keys[1])
+	for _, key := range keys {
+		pipeline.Expire(key, pollKeepTime)
+	}
+	if _, err = pipeline.Exec(); err != nil {
+		return
+	}
+	votes, err = parsePollCount(countCmd.Val())
+	return votes, votersCmd.Val(), err
+}
+
+func parsePollCount(m map[string]string) (map[int]int64, error) {
+	votes := make(map[int]int64, len(m))
+	for k, v := range m {
+		option, err := strconv.Atoi(k)
+		if err != nil {
+			return nil, err
+		}
+		if votes[option], err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	return votes, nil
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
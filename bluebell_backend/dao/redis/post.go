@@ -15,15 +15,23 @@ func getIDsFormKey(key string, page, size int64) ([]string, error) {
 	return client.ZRevRange(key, start, end).Result()
 }
 
+// postOrderKey 根据排序规则确定帖子排序ZSet的key
+func postOrderKey(order string) string {
+	switch order {
+	case models.OrderScore: // 按照分数请求
+		return KeyPostScoreZSet
+	case models.OrderComments: // 按照评论数请求
+		return KeyPostCommentZSet
+	}
+	return KeyPostTimeZSet // 默认是时间
+}
+
 // GetPostIDsInOrder 根据排序规则查询所有ids
 func GetPostIDsInOrder(p *models.ParamPostList) ([]string, error) {
 	// 1.根据用户请求中携带的order参数确定要查询的redisKey
-	key := KeyPostTimeZSet            // 默认是时间
-	if p.Order == models.OrderScore { // 按照分数请求
-		key = KeyPostScoreZSet
-	}
-	// 2.查询ids范围 [(page-1)*size, (page-1)*size + size)
-	return getIDsFormKey(key, p.Page, p.Size)
+	key := postOrderKey(p.Order)
+	// 2.查询ids范围 [(page-1)*size, (page-1)*size + size)，置顶帖子排在最前面
+	return getIDsWithPinned(key, KeyPostPinnedZSet, p.Page, p.Size)
 }
 
 // GetPostVoteData 根据ids查询每篇帖子的赞成票数量
@@ -65,10 +73,7 @@ func GetPostVoteNum(ids int64) (data int64, err error) {
 // GetCommunityPostIDsInOrder  根据order查询community_id社区的ids
 func GetCommunityPostIDsInOrder(p *models.ParamPostList) ([]string, error) {
 	// 1.根据用户请求中携带的order参数确定要查询的redis key
-	orderkey := KeyPostTimeZSet       // 默认是时间
-	if p.Order == models.OrderScore { // 按照分数请求
-		orderkey = KeyPostScoreZSet
-	}
+	orderkey := postOrderKey(p.Order)
 
 	// 使用ZInterStore 将存储某社区下所有帖子ID的Set 与 存储所有帖子得分信息的ZSet 交集生成一个新的ZSet
 	// 新的ZSet存储的就是该社区下所有帖子得分信息
@@ -90,6 +95,47 @@ func GetCommunityPostIDsInOrder(p *models.ParamPostList) ([]string, error) {
 			return nil, err
 		}
 	}
-	// 存在的就直接根据key查询ids
-	return getIDsFormKey(key, p.Page, p.Size)
+	// 存在的就直接根据key查询ids，社区的置顶帖子排在最前面
+	return getIDsWithPinned(key, KeyCommunityPinnedZSetPrefix+strconv.FormatUint(p.CommunityID, 10), p.Page, p.Size)
+}
+
+// RemovePost 删除帖子在redis中的缓存，帖子不再出现在帖子列表中
+func RemovePost(postID, communityID uint64, tags []string) (err error) {
+	pid := strconv.FormatUint(postID, 10)
+	pipeline := client.TxPipeline()
+	pipeline.ZRem(KeyPostTimeZSet, pid)
+	pipeline.ZRem(KeyPostScoreZSet, pid)
+	pipeline.ZRem(KeyPostCommentZSet, pid)
+	pipeline.SRem(KeyCommunityPostSetPrefix+strconv.FormatUint(communityID, 10), pid)
+	pipeline.Del(KeyPostInfoHashPrefix+pid, KeyPostVotedZSetPrefix+pid,
+		KeyCommentScoreZSetPrefix+pid, KeyCommentBestZSetPrefix+pid, KeyCommentVoteHashPrefix+pid, KeyCommentIndexedPrefix+pid)
+	pipeline.Del(communityOrderKeys(communityID)...)
+	unpinPost(pipeline, pid, communityID)
+	changePostTags(pipeline, postID, tags, -1)
+	_, err = pipeline.Exec()
+	return
+}
+
+// communityOrderKeys GetCommunityPostIDsInOrder缓存的社区帖子排序ZSet，社区帖子变化时删除
+func communityOrderKeys(communityID uint64) []string {
+	cid := strconv.FormatUint(communityID, 10)
+	return []string{KeyPostTimeZSet + cid, KeyPostScoreZSet + cid, KeyPostCommentZSet + cid}
+}
+
+// UpdatePost 帖子编辑后同步redis中的帖子信息，所属社区变化时移动到新社区
+func UpdatePost(postID uint64, title, summary string, oldCommunityID, newCommunityID uint64) (err error) {
+	pid := strconv.FormatUint(postID, 10)
+	pipeline := client.TxPipeline()
+	pipeline.HMSet(KeyPostInfoHashPrefix+pid, map[string]interface{}{
+		"title":   title,
+		"summary": summary,
+	})
+	if oldCommunityID != newCommunityID {
+		pipeline.SRem(KeyCommunityPostSetPrefix+strconv.FormatUint(oldCommunityID, 10), pid)
+		pipeline.SAdd(KeyCommunityPostSetPrefix+strconv.FormatUint(newCommunityID, 10), pid)
+		unpinPost(pipeline, pid, oldCommunityID) // 移动到其他社区时取消置顶
+		pipeline.Del(append(communityOrderKeys(oldCommunityID), communityOrderKeys(newCommunityID)...)...)
+	}
+	_, err = pipeline.Exec()
+	return
 }
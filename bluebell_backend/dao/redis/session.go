@@ -0,0 +1,110 @@
+package redis
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+/*
+限制账号同时登录的设备数
+	* 每个用户一个ZSet [bluebell:session:user_id, (token_id, 过期时间)]
+	* Token所属的家族记录在 [bluebell:session:family:user_id, {token_id: family_id}]
+	* 登录/刷新时登记新Token，超出设备数上限时移除最早过期(即最早登录)的Token，并返回其家族，由调用方吊销，
+	  否则被挤下线的设备仍可以用refresh_token重新登记，反过来挤掉新设备
+	* key的过期时间与最新Token的过期时间相同，避免Token过期但Redis中仍存在旧记录
+*/
+
+// sessionScript 登记Token并清理过期及超出上限的Token，返回被挤下线的Token家族
+// KEYS[1] 登录设备ZSet KEYS[2] Token家族Hash
+// ARGV[1] 当前时间 ARGV[2] 被替换的token_id(登录时为空) ARGV[3] 新token_id ARGV[4] 过期时间 ARGV[5] 设备数上限 ARGV[6] 家族
+var sessionScript = redis.NewScript(`
+local expired = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+for _, id in ipairs(expired) do
+	redis.call('ZREM', KEYS[1], id)
+	redis.call('HDEL', KEYS[2], id)
+end
+if ARGV[2] ~= '' then
+	redis.call('ZREM', KEYS[1], ARGV[2])
+	redis.call('HDEL', KEYS[2], ARGV[2])
+end
+redis.call('ZADD', KEYS[1], ARGV[4], ARGV[3])
+redis.call('HSET', KEYS[2], ARGV[3], ARGV[6])
+local evicted = {}
+local n = redis.call('ZCARD', KEYS[1]) - tonumber(ARGV[5])
+if n > 0 then
+	-- 按过期时间升序取最早的Token，跳过刚登记的Token
+	for _, id in ipairs(redis.call('ZRANGE', KEYS[1], 0, n)) do
+		if n == 0 then
+			break
+		end
+		if id ~= ARGV[3] then
+			local family = redis.call('HGET', KEYS[2], id)
+			if family and family ~= ARGV[6] then
+				table.insert(evicted, family)
+			end
+			redis.call('ZREM', KEYS[1], id)
+			redis.call('HDEL', KEYS[2], id)
+			n = n - 1
+		end
+	end
+end
+redis.call('EXPIREAT', KEYS[1], ARGV[4])
+redis.call('EXPIREAT', KEYS[2], ARGV[4])
+return evicted
+`)
+
+// addSession 登记Token，返回超出设备数上限被挤下线的Token家族
+func addSession(userID uint64, family, oldTokenID, tokenID string, expireAt time.Time, maxDevices int) (evicted []string, err error) {
+	if maxDevices < 1 {
+		maxDevices = 1
+	}
+	uid := strconv.FormatUint(userID, 10)
+	keys := []string{KeySessionZSetPrefix + uid, KeySessionFamilyHashPrefix + uid}
+	res, err := sessionScript.Run(client, keys,
+		time.Now().Unix(), oldTokenID, tokenID, expireAt.Unix(), maxDevices, family).Result()
+	if err != nil {
+		return
+	}
+	values, _ := res.([]interface{})
+	for _, v := range values {
+		if f, ok := v.(string); ok {
+			evicted = append(evicted, f)
+		}
+	}
+	return
+}
+
+// SaveSession 登录时登记用户的Access Token，返回被挤下线的Token家族
+func SaveSession(userID uint64, family, tokenID string, expireAt time.Time, maxDevices int) ([]string, error) {
+	return addSession(userID, family, "", tokenID, expireAt, maxDevices)
+}
+
+// RefreshSession 刷新Token时用新Token替换旧Token，返回被挤下线的Token家族
+func RefreshSession(userID uint64, family, oldTokenID, newTokenID string, expireAt time.Time, maxDevices int) ([]string, error) {
+	return addSession(userID, family, oldTokenID, newTokenID, expireAt, maxDevices)
+}
+
+// CheckSession 判断Token是否为用户当前有效的登录Token
+func CheckSession(userID uint64, tokenID string) (bool, error) {
+	key := KeySessionZSetPrefix + strconv.FormatUint(userID, 10)
+	expireAt, err := client.ZScore(key, tokenID).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return expireAt > float64(time.Now().Unix()), nil
+}
+
+// RemoveSession 注销时移除用户的某个Token
+func RemoveSession(userID uint64, tokenID string) (err error) {
+	uid := strconv.FormatUint(userID, 10)
+	pipeline := client.TxPipeline()
+	pipeline.ZRem(KeySessionZSetPrefix+uid, tokenID)
+	pipeline.HDel(KeySessionFamilyHashPrefix+uid, tokenID)
+	_, err = pipeline.Exec()
+	return
+}
@@ -0,0 +1,116 @@
+package redis
+
+import (
+	"bluebell_backend/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// postTagsScript 将帖子加入/移出标签集合，只有集合实际变化时才更新标签的帖子数量，保证重复执行不会重复计数
+// KEYS[1] 标签帖子数量ZSet KEYS[2...] 标签帖子Set ARGV[1] post_id ARGV[2] 1:加入 -1:移出 ARGV[3...] 标签
+var postTagsScript = redis.NewScript(`
+local add = tonumber(ARGV[2]) > 0
+for i = 2, #KEYS do
+	local tag = ARGV[i + 1]
+	if add then
+		if redis.call('SADD', KEYS[i], ARGV[1]) == 1 then
+			redis.call('ZINCRBY', KEYS[1], 1, tag)
+		end
+	elseif redis.call('SREM', KEYS[i], ARGV[1]) == 1 then
+		if tonumber(redis.call('ZINCRBY', KEYS[1], -1, tag)) <= 0 then
+			redis.call('ZREM', KEYS[1], tag)
+		end
+	end
+end
+return 0
+`)
+
+// changePostTags 在pipeline中将帖子加入(delta=1)或移出(delta=-1)标签集合
+func changePostTags(pipeline redis.Pipeliner, postID uint64, tags []string, delta int) {
+	if len(tags) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(tags)+1)
+	args := make([]interface{}, 0, len(tags)+2)
+	keys = append(keys, KeyTagPopularZSet)
+	args = append(args, postID, delta)
+	for _, tag := range tags {
+		keys = append(keys, KeyTagPostSetPrefix+tag)
+		args = append(args, tag)
+	}
+	postTagsScript.Eval(pipeline, keys, args...)
+}
+
+// UpdatePostTags 帖子标签修改后同步redis中的标签集合
+func UpdatePostTags(postID uint64, oldTags, newTags []string) (err error) {
+	pipeline := client.TxPipeline()
+	changePostTags(pipeline, postID, oldTags, -1)
+	changePostTags(pipeline, postID, newTags, 1)
+	_, err = pipeline.Exec()
+	return
+}
+
+// GetTagPostIDsInOrder 根据order查询同时包含所有标签的帖子ids，指定社区时只查询该社区的帖子
+func GetTagPostIDsInOrder(p *models.ParamPostList) (ids []string, total int64, err error) {
+	orderKey := postOrderKey(p.Order)
+
+	// 与GetCommunityPostIDsInOrder相同：将标签的帖子Set与排序ZSet求交集，结果缓存60s
+	tags := append([]string(nil), p.Tags...)
+	sort.Strings(tags)
+	keys := make([]string, 0, len(tags)+2)
+	for _, tag := range tags {
+		keys = append(keys, KeyTagPostSetPrefix+tag)
+	}
+	key := orderKey + ":tag:" + strings.Join(tags, ",") // 新ZSet的key
+	if p.CommunityID != 0 {
+		cid := strconv.FormatUint(p.CommunityID, 10)
+		keys = append(keys, KeyCommunityPostSetPrefix+cid)
+		key += ":community:" + cid
+	}
+	keys = append(keys, orderKey)
+
+	if client.Exists(key).Val() < 1 {
+		pipeline := client.Pipeline()
+		pipeline.ZInterStore(key, redis.ZStore{
+			Aggregate: "MAX",
+		}, keys...)
+		pipeline.Expire(key, 60*time.Second)
+		if _, err = pipeline.Exec(); err != nil {
+			return
+		}
+	}
+	if total, err = client.ZCard(key).Result(); err != nil {
+		return
+	}
+	ids, err = getIDsFormKey(key, p.Page, p.Size)
+	return
+}
+
+// GetPopularTags 按帖子数量降序查询热门标签
+func GetPopularTags(size int64) ([]*models.ApiTag, error) {
+	zs, err := client.ZRevRangeWithScores(KeyTagPopularZSet, 0, size-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]*models.ApiTag, 0, len(zs))
+	for _, z := range zs {
+		tags = append(tags, &models.ApiTag{
+			Name:      z.Member.(string),
+			PostCount: int64(z.Score),
+		})
+	}
+	return tags, nil
+}
+
+// GetTagPostCount 查询标签下已发布的帖子数量
+func GetTagPostCount(tag string) (int64, error) {
+	score, err := client.ZScore(KeyTagPopularZSet, tag).Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return int64(score), err
+}
@@ -0,0 +1,160 @@
+package redis
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+/*
+Token吊销
+	* 吊销的Token按jti记录到黑名单 [bluebell:token:revoked:jti]，过期时间与Token剩余有效期相同
+	* 一次登录签发的Token属于同一家族，家族当前有效的Token记录在
+	  Hash [bluebell:token:family:fid, {user_id, refresh, refresh_exp, access, access_exp}]
+	* refresh_token只能使用一次，使用后轮换为新Token；已被轮换的refresh_token再次使用视为重放，吊销整个家族
+	* 每个用户的Token家族记录在 [bluebell:token:family:user:user_id, (fid, 过期时间)]，注销所有设备时全部吊销
+*/
+
+// rotateScript 校验提交的refresh_token是否为家族当前的Token，是则原子地替换为新Token
+// 返回 {1, 旧access jti} 成功；{0} 家族不存在(已吊销或过期)；{-1} refresh_token已被使用过(重放)
+var rotateScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], 'refresh')
+if not current then
+	return {0}
+end
+if current ~= ARGV[1] then
+	return {-1}
+end
+local oldAccess = redis.call('HGET', KEYS[1], 'access') or ''
+redis.call('HMSET', KEYS[1], 'refresh', ARGV[2], 'refresh_exp', ARGV[3], 'access', ARGV[4], 'access_exp', ARGV[5])
+redis.call('EXPIREAT', KEYS[1], ARGV[3])
+return {1, oldAccess}
+`)
+
+// RevokeToken 将Token加入黑名单，Token过期后黑名单记录自动删除
+func RevokeToken(tokenID string, expireAt time.Time) (err error) {
+	ttl := time.Until(expireAt)
+	if tokenID == "" || ttl <= 0 { // 已经过期的Token无需记录
+		return nil
+	}
+	return client.Set(KeyTokenRevokedPrefix+tokenID, 1, ttl).Err()
+}
+
+// IsTokenRevoked 判断Token是否已被吊销
+func IsTokenRevoked(tokenID string) (bool, error) {
+	n, err := client.Exists(KeyTokenRevokedPrefix + tokenID).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// SaveTokenFamily 登录时记录新的Token家族
+func SaveTokenFamily(userID uint64, family, refreshID string, refreshExp time.Time, accessID string, accessExp time.Time) (err error) {
+	familyKey := KeyTokenFamilyPrefix + family
+	userKey := KeyUserTokenFamilyZSetPrefix + strconv.FormatUint(userID, 10)
+	pipeline := client.TxPipeline()
+	pipeline.HMSet(familyKey, map[string]interface{}{
+		"user_id":     userID,
+		"refresh":     refreshID,
+		"refresh_exp": refreshExp.Unix(),
+		"access":      accessID,
+		"access_exp":  accessExp.Unix(),
+	})
+	pipeline.ExpireAt(familyKey, refreshExp)
+	pipeline.ZRemRangeByScore(userKey, "-inf", strconv.FormatInt(time.Now().Unix(), 10))
+	pipeline.ZAdd(userKey, redis.Z{
+		Score:  float64(refreshExp.Unix()),
+		Member: family,
+	})
+	pipeline.ExpireAt(userKey, refreshExp)
+	_, err = pipeline.Exec()
+	return
+}
+
+// RotateTokenFamily 使用refresh_token时将家族的Token轮换为新Token，返回被替换的access jti
+func RotateTokenFamily(userID uint64, family, usedRefreshID, refreshID string, refreshExp time.Time, accessID string, accessExp time.Time) (oldAccessID string, err error) {
+	familyKey := KeyTokenFamilyPrefix + family
+	res, err := rotateScript.Run(client, []string{familyKey},
+		usedRefreshID, refreshID, refreshExp.Unix(), accessID, accessExp.Unix()).Result()
+	if err != nil {
+		return
+	}
+	values, _ := res.([]interface{})
+	if len(values) == 0 {
+		return "", ErrorTokenFamilyNotFound
+	}
+	switch status, _ := values[0].(int64); status {
+	case 0:
+		return "", ErrorTokenFamilyNotFound
+	case -1:
+		return "", ErrorRefreshTokenReused
+	}
+	if len(values) > 1 {
+		oldAccessID, _ = values[1].(string)
+	}
+	// 家族有效期随轮换延长
+	userKey := KeyUserTokenFamilyZSetPrefix + strconv.FormatUint(userID, 10)
+	pipeline := client.TxPipeline()
+	pipeline.ZAdd(userKey, redis.Z{
+		Score:  float64(refreshExp.Unix()),
+		Member: family,
+	})
+	pipeline.ExpireAt(userKey, refreshExp)
+	_, err = pipeline.Exec()
+	return
+}
+
+// revokeFamilies 吊销多个Token家族：当前的Token加入黑名单，并删除家族及登录记录
+func revokeFamilies(families ...string) (err error) {
+	if len(families) == 0 {
+		return nil
+	}
+	pipeline := client.Pipeline()
+	cmds := make([]*redis.StringStringMapCmd, 0, len(families))
+	for _, family := range families {
+		cmds = append(cmds, pipeline.HGetAll(KeyTokenFamilyPrefix+family))
+	}
+	if _, err = pipeline.Exec(); err != nil && err != redis.Nil {
+		return
+	}
+
+	tx := client.TxPipeline()
+	for idx, cmd := range cmds {
+		info := cmd.Val()
+		for _, field := range [][2]string{{"refresh", "refresh_exp"}, {"access", "access_exp"}} {
+			exp, _ := strconv.ParseInt(info[field[1]], 10, 64)
+			ttl := time.Until(time.Unix(exp, 0))
+			if info[field[0]] != "" && ttl > 0 {
+				tx.Set(KeyTokenRevokedPrefix+info[field[0]], 1, ttl)
+			}
+		}
+		if uid := info["user_id"]; uid != "" {
+			tx.ZRem(KeySessionZSetPrefix+uid, info["access"])
+			tx.HDel(KeySessionFamilyHashPrefix+uid, info["access"])
+			tx.ZRem(KeyUserTokenFamilyZSetPrefix+uid, families[idx])
+		}
+		tx.Del(KeyTokenFamilyPrefix + families[idx])
+	}
+	_, err = tx.Exec()
+	return
+}
+
+// RevokeTokenFamily 吊销Token家族，用于注销当前设备、检测到refresh_token重放或设备被挤下线
+func RevokeTokenFamily(families ...string) error {
+	return revokeFamilies(families...)
+}
+
+// RevokeUserTokens 吊销用户所有的Token家族，即注销所有设备
+func RevokeUserTokens(userID uint64) (err error) {
+	uid := strconv.FormatUint(userID, 10)
+	families, err := client.ZRange(KeyUserTokenFamilyZSetPrefix+uid, 0, -1).Result()
+	if err != nil {
+		return
+	}
+	if err = revokeFamilies(families...); err != nil {
+		return
+	}
+	return client.Del(KeySessionZSetPrefix+uid, KeySessionFamilyHashPrefix+uid, KeyUserTokenFamilyZSetPrefix+uid).Err()
+}
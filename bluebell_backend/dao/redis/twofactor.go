@@ -0,0 +1,57 @@
+package redis
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// SaveTwoFactorChallenge 保存登录第二步的临时凭证 Hash [bluebell:2fa:challenge:sha256(token), {user_id, attempts}]
+func SaveTwoFactorChallenge(token string, userID uint64, ttl time.Duration) (err error) {
+	key := oneTimeTokenKey(KeyTwoFactorChallengePrefix, token)
+	pipeline := client.TxPipeline()
+	pipeline.HMSet(key, map[string]interface{}{
+		"user_id":  userID,
+		"attempts": 0,
+	})
+	pipeline.Expire(key, ttl)
+	_, err = pipeline.Exec()
+	return
+}
+
+// checkChallengeScript 凭证存在时累加尝试次数，返回 {user_id, 尝试次数}；凭证不存在时返回nil
+// 不能先查询再HINCRBY，否则凭证恰好在两次操作之间过期时会重新创建一个没有过期时间的key
+// KEYS[1] 临时凭证Hash
+var checkChallengeScript = redis.NewScript(`
+local uid = redis.call('HGET', KEYS[1], 'user_id')
+if not uid then
+	return false
+end
+return {uid, redis.call('HINCRBY', KEYS[1], 'attempts', 1)}
+`)
+
+// CheckTwoFactorChallenge 查询临时凭证对应的用户，并累加尝试次数
+// 凭证不存在或已过期时返回redis.Nil
+func CheckTwoFactorChallenge(token string) (userID uint64, attempts int64, err error) {
+	key := oneTimeTokenKey(KeyTwoFactorChallengePrefix, token)
+	res, err := checkChallengeScript.Run(client, []string{key}).Result()
+	if err != nil {
+		return
+	}
+	values, _ := res.([]interface{})
+	if len(values) != 2 {
+		return 0, 0, redis.Nil
+	}
+	uid, _ := values[0].(string)
+	if userID, err = strconv.ParseUint(uid, 10, 64); err != nil {
+		return
+	}
+	attempts, _ = values[1].(int64)
+	return
+}
+
+// DeleteTwoFactorChallenge 删除临时凭证，验证成功或尝试次数过多时调用
+func DeleteTwoFactorChallenge(token string) error {
+	return client.Del(oneTimeTokenKey(KeyTwoFactorChallengePrefix, token)).Err()
+}
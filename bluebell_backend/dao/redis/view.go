@@ -0,0 +1,113 @@
+package redis
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+/*
+帖子浏览数
+	* 每篇帖子每天一个HyperLogLog [bluebell:post:view:post_id:日期, 访客]，同一访客一天内只计一次浏览
+	* 有新浏览的帖子加入当天的Set，同步任务从Set中取出帖子，将当天已同步部分之外的浏览数累加到MySQL
+	* 当天已同步的浏览数记录在Hash中，所有key在两天后过期
+	* 未登录访客的IP使用每天随机生成的密钥计算HMAC，所有实例共用同一密钥，密钥过期后无法再还原IP
+*/
+
+const (
+	viewDateLayout     = "20060102"
+	viewKeyExpiration  = 48 * time.Hour
+	viewKeyExpireInSec = int64(viewKeyExpiration / time.Second)
+)
+
+// viewDate 浏览记录使用的日期
+func viewDate(t time.Time) string {
+	return t.Format(viewDateLayout)
+}
+
+// ViewDates 需要同步浏览数的日期：昨天和今天，昨天临近零点的浏览可能尚未同步
+func ViewDates(now time.Time) []string {
+	return []string{viewDate(now.AddDate(0, 0, -1)), viewDate(now)}
+}
+
+// RecordPostView 记录访客对帖子的浏览
+func RecordPostView(postID uint64, visitor string, t time.Time) (err error) {
+	pid := strconv.FormatUint(postID, 10)
+	date := viewDate(t)
+	hllKey := KeyPostViewHLLPrefix + pid + ":" + date
+	dirtyKey := KeyPostViewDirtySetPrefix + date
+	pipeline := client.TxPipeline()
+	pipeline.PFAdd(hllKey, visitor)
+	pipeline.Expire(hllKey, viewKeyExpiration)
+	pipeline.SAdd(dirtyKey, pid)
+	pipeline.Expire(dirtyKey, viewKeyExpiration)
+	_, err = pipeline.Exec()
+	return
+}
+
+// GetViewSalt 查询当天计算访客IP哈希值的密钥，不存在时保存candidate作为当天的密钥
+func GetViewSalt(t time.Time, candidate string) (date, salt string, err error) {
+	date = viewDate(t)
+	key := KeyPostViewSaltPrefix + date
+	if _, err = client.SetNX(key, candidate, viewKeyExpiration).Result(); err != nil {
+		return
+	}
+	salt, err = client.Get(key).Result()
+	return
+}
+
+// PopViewedPosts 取出某天有新浏览的帖子，最多count个
+func PopViewedPosts(date string, count int64) ([]string, error) {
+	ids, err := client.SPopN(KeyPostViewDirtySetPrefix+date, count).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return ids, err
+}
+
+// takeViewDeltaScript 计算帖子当天尚未同步的浏览数，并记为已同步
+// KEYS[1] 帖子当天的HyperLogLog KEYS[2] 当天已同步的浏览数Hash ARGV[1] post_id ARGV[2] 过期时间(秒)
+var takeViewDeltaScript = redis.NewScript(`
+local count = redis.call('PFCOUNT', KEYS[1])
+local flushed = tonumber(redis.call('HGET', KEYS[2], ARGV[1]) or '0')
+if count <= flushed then
+	return 0
+end
+redis.call('HSET', KEYS[2], ARGV[1], count)
+redis.call('EXPIRE', KEYS[2], ARGV[2])
+return count - flushed
+`)
+
+// TakePostViewDelta 取出帖子某天尚未同步到MySQL的独立访客数
+func TakePostViewDelta(date, postID string) (int64, error) {
+	keys := []string{KeyPostViewHLLPrefix + postID + ":" + date, KeyPostViewFlushedHashPrefix + date}
+	return takeViewDeltaScript.Run(client, keys, postID, viewKeyExpireInSec).Int64()
+}
+
+// RestorePostViewDelta 同步到MySQL失败时撤销TakePostViewDelta，下次同步时重试
+func RestorePostViewDelta(date, postID string, delta int64) (err error) {
+	pipeline := client.TxPipeline()
+	pipeline.HIncrBy(KeyPostViewFlushedHashPrefix+date, postID, -delta)
+	pipeline.SAdd(KeyPostViewDirtySetPrefix+date, postID)
+	pipeline.Expire(KeyPostViewDirtySetPrefix+date, viewKeyExpiration)
+	_, err = pipeline.Exec()
+	return
+}
+
+// incrViewScoreScript 发布一周内的帖子按浏览数增加热度分数，与投票的时间限制相同
+// KEYS[1] 帖子时间ZSet KEYS[2] 帖子分数ZSet ARGV[1] post_id ARGV[2] 增加的分数 ARGV[3] 当前时间 ARGV[4] 一周的秒数
+var incrViewScoreScript = redis.NewScript(`
+local t = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not t or tonumber(ARGV[3]) - tonumber(t) > tonumber(ARGV[4]) then
+	return 0
+end
+redis.call('ZINCRBY', KEYS[2], ARGV[2], ARGV[1])
+return 1
+`)
+
+// IncrPostViewScore 浏览增加帖子的热度分数，已删除或发布超过一周的帖子不变
+func IncrPostViewScore(postID string, score float64) error {
+	return incrViewScoreScript.Run(client, []string{KeyPostTimeZSet, KeyPostScoreZSet},
+		postID, score, time.Now().Unix(), OneWeekInSeconds).Err()
+}
@@ -78,7 +78,8 @@ func VoteForPost(userID string, postID string, v float64) (err error) {
 }
 
 // CreatePost redis存储帖子相关信息
-func CreatePost(postID, userID uint64, title, summary string, CommunityID uint64) (err error) {
+// 只写入不存在的数据，重复执行(如多个实例同时发布同一帖子)不会重置已有的时间、得分和评论数
+func CreatePost(postID, userID uint64, title, summary string, CommunityID uint64, tags []string) (err error) {
 	now := float64(time.Now().Unix())
 	votedKey := KeyPostVotedZSetPrefix + strconv.Itoa(int(postID))             // bluebell:post:voted:post_id
 	communityKey := KeyCommunityPostSetPrefix + strconv.Itoa(int(CommunityID)) // bluebell:community:community_id
@@ -95,25 +96,35 @@ func CreatePost(postID, userID uint64, title, summary string, CommunityID uint64
 	// 事务操作：确保所有 Redis 操作要么全部成功，要么全部失败
 	pipeline := client.TxPipeline()
 	// 存储帖子投票信息 ZSet [bluebell:post:voted:post_id, (userID, score)]
-	pipeline.ZAdd(votedKey, redis.Z{
+	pipeline.ZAddNX(votedKey, redis.Z{
 		Score:  1, // 作者默认投赞成票
 		Member: userID,
 	})
 	pipeline.Expire(votedKey, time.Second*OneMonthInSeconds*6) // 过期时间为6个月
 	// 存储帖子得分信息 ZSet [bluebell:post:score, (post_id, score)]
-	pipeline.ZAdd(KeyPostScoreZSet, redis.Z{
+	pipeline.ZAddNX(KeyPostScoreZSet, redis.Z{
 		Score:  now + VoteScore,
 		Member: postID,
 	})
 	// 存储帖子发布时间信息 ZSet [bluebell:post:time, (post_id, score)]
-	pipeline.ZAdd(KeyPostTimeZSet, redis.Z{
+	pipeline.ZAddNX(KeyPostTimeZSet, redis.Z{
 		Score:  now,
 		Member: postID,
 	})
+	// 存储帖子评论数 ZSet [bluebell:post:comments, (post_id, comment_num)]
+	pipeline.ZAddNX(KeyPostCommentZSet, redis.Z{
+		Score:  0,
+		Member: postID,
+	})
 	// 存储帖子详细信息 Hash [bluebell:post:post_id, postInfo]
-	pipeline.HMSet(KeyPostInfoHashPrefix+strconv.Itoa(int(postID)), postInfo)
+	infoKey := KeyPostInfoHashPrefix + strconv.Itoa(int(postID))
+	for field, value := range postInfo {
+		pipeline.HSetNX(infoKey, field, value)
+	}
 	// 存储某社区下所有帖子ID Set [bluebell:community:community_id, post_id]
 	pipeline.SAdd(communityKey, postID)
+	// 存储某标签下所有帖子ID Set [bluebell:tag:post:tag, post_id]
+	changePostTags(pipeline, postID, tags, 1)
 	_, err = pipeline.Exec()
 	return
 }
@@ -165,3 +176,155 @@ func Hot(ups, downs int, date time.Time) float64 {
 	seconds := float64(date.Second() - 1577808000)
 	return math.Round(sign*order + seconds/43200)
 }
+
+// GetUserKarma 统计用户帖子收到的赞成票数减去反对票数，作者给自己帖子的投票不计入
+func GetUserKarma(userID uint64, postIDs []string) (karma int64, err error) {
+	if len(postIDs) == 0 {
+		return 0, nil
+	}
+	uid := strconv.FormatUint(userID, 10)
+	pipeline := client.Pipeline()
+	ups := make([]*redis.IntCmd, 0, len(postIDs))
+	downs := make([]*redis.IntCmd, 0, len(postIDs))
+	selfs := make([]*redis.FloatCmd, 0, len(postIDs))
+	for _, id := range postIDs {
+		key := KeyPostVotedZSetPrefix + id
+		ups = append(ups, pipeline.ZCount(key, "1", "1"))
+		downs = append(downs, pipeline.ZCount(key, "-1", "-1"))
+		selfs = append(selfs, pipeline.ZScore(key, uid))
+	}
+	// 作者未投票时ZScore返回redis.Nil，不视为错误
+	if _, err = pipeline.Exec(); err != nil && err != redis.Nil {
+		return
+	}
+	for i := range postIDs {
+		karma += ups[i].Val() - downs[i].Val() - int64(selfs[i].Val())
+	}
+	return karma, nil
+}
+
+// commentVoteScript 为评论投票：记录用户的投票，更新评论的赞成票/反对票数量，
+// 一级评论同时更新得分(每票VoteScore分)和Wilson得分，返回-1表示重复投票
+// KEYS[1] 用户在帖子中的评论投票Hash KEYS[2] 帖子的评论票数Hash KEYS[3] 一级评论得分ZSet KEYS[4] 一级评论Wilson得分ZSet
+// ARGV[1] comment_id ARGV[2] 投票方向 1/0/-1 ARGV[3] 每票的分数 ARGV[4] Wilson得分的z值
+var commentVoteScript = redis.NewScript(`
+local id = ARGV[1]
+local old = tonumber(redis.call('HGET', KEYS[1], id) or '0')
+local new = tonumber(ARGV[2])
+if old == new then
+	return -1
+end
+if new == 0 then
+	redis.call('HDEL', KEYS[1], id)
+else
+	redis.call('HSET', KEYS[1], id, new)
+end
+if old == 1 then
+	redis.call('HINCRBY', KEYS[2], id .. ':up', -1)
+elseif old == -1 then
+	redis.call('HINCRBY', KEYS[2], id .. ':down', -1)
+end
+if new == 1 then
+	redis.call('HINCRBY', KEYS[2], id .. ':up', 1)
+elseif new == -1 then
+	redis.call('HINCRBY', KEYS[2], id .. ':down', 1)
+end
+-- 已删除的一级评论不在ZSet中，不再更新得分
+if redis.call('ZSCORE', KEYS[3], id) then
+	redis.call('ZINCRBY', KEYS[3], (new - old) * tonumber(ARGV[3]), id)
+	local ups = tonumber(redis.call('HGET', KEYS[2], id .. ':up') or '0')
+	local n = ups + tonumber(redis.call('HGET', KEYS[2], id .. ':down') or '0')
+	local score = 0
+	if n > 0 then
+		local z = tonumber(ARGV[4])
+		local p = ups / n
+		score = (p + z * z / (2 * n) - z * math.sqrt((p * (1 - p) + z * z / (4 * n)) / n)) / (1 + z * z / n)
+	end
+	redis.call('ZADD', KEYS[4], score, id)
+end
+return 1
+`)
+
+// WilsonZ 计算Wilson得分下界使用的z值，对应80%的置信度
+const WilsonZ = 1.281551565545
+
+// WilsonScore 评论赞成率的Wilson置信区间下界，与commentVoteScript中的计算相同
+func WilsonScore(ups, downs int64) float64 {
+	n := float64(ups + downs)
+	if n == 0 {
+		return 0
+	}
+	p := float64(ups) / n
+	z := WilsonZ
+	return (p + z*z/(2*n) - z*math.Sqrt((p*(1-p)+z*z/(4*n))/n)) / (1 + z*z/n)
+}
+
+// VoteForComment 为评论投票 direction: 1赞成 -1反对 0取消
+func VoteForComment(userID, postID, commentID uint64, direction int8) error {
+	pid := strconv.FormatUint(postID, 10)
+	keys := []string{
+		KeyCommentVotedHashPrefix + pid + ":" + strconv.FormatUint(userID, 10),
+		KeyCommentVoteHashPrefix + pid,
+		KeyCommentScoreZSetPrefix + pid,
+		KeyCommentBestZSetPrefix + pid,
+	}
+	ret, err := commentVoteScript.Run(client, keys, commentID, direction, VoteScore, WilsonZ).Int64()
+	if err != nil {
+		return err
+	}
+	if ret < 0 {
+		return ErrVoteRepeated
+	}
+	return nil
+}
+
+// GetCommentVotes 批量查询帖子中评论的赞成票/反对票数量，userID不为0时同时查询该用户的投票
+func GetCommentVotes(postID, userID uint64, commentIDs []uint64) (ups, downs map[uint64]int64, voted map[uint64]int8, err error) {
+	ups = make(map[uint64]int64, len(commentIDs))
+	downs = make(map[uint64]int64, len(commentIDs))
+	voted = make(map[uint64]int8)
+	if len(commentIDs) == 0 {
+		return
+	}
+	pid := strconv.FormatUint(postID, 10)
+	fields := make([]string, 0, 2*len(commentIDs))
+	for _, id := range commentIDs {
+		cid := strconv.FormatUint(id, 10)
+		fields = append(fields, cid+":up", cid+":down")
+	}
+	pipeline := client.Pipeline()
+	counts := pipeline.HMGet(KeyCommentVoteHashPrefix+pid, fields...)
+	var userVotes *redis.StringStringMapCmd
+	if userID != 0 {
+		userVotes = pipeline.HGetAll(KeyCommentVotedHashPrefix + pid + ":" + strconv.FormatUint(userID, 10))
+	}
+	if _, err = pipeline.Exec(); err != nil {
+		return
+	}
+	values := counts.Val()
+	for i, id := range commentIDs {
+		ups[id] = parseCount(values[2*i])
+		downs[id] = parseCount(values[2*i+1])
+	}
+	if userVotes != nil {
+		for cid, v := range userVotes.Val() {
+			id, err := strconv.ParseUint(cid, 10, 64)
+			if err != nil {
+				continue
+			}
+			direction, _ := strconv.ParseInt(v, 10, 8)
+			voted[id] = int8(direction)
+		}
+	}
+	return
+}
+
+// parseCount 解析HMGET返回的计数，字段不存在时为0
+func parseCount(v interface{}) int64 {
+	s, ok := v.(string)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
@@ -0,0 +1,154 @@
+package logic
+
+import (
+	"bluebell_backend/dao/mysql"
+	"bluebell_backend/dao/redis"
+	"bluebell_backend/models"
+	"database/sql"
+)
+
+/*
+角色与权限
+	* 用户角色保存在MySQL user表中，登录时写入Token，变更角色后吊销用户所有Token，重新登录后生效
+	* 版主只能管理被指派的社区，指派关系保存在community_moderator表中
+	* 管理员拥有所有权限
+	* 版主可以置顶、锁定帖子和设为精华，每个社区最多置顶maxPinnedPosts篇帖子
+*/
+
+const maxPinnedPosts = 5 // 每个社区置顶帖子的数量上限
+
+// SetUserRole 设置用户角色
+func SetUserRole(userID uint64, role string) (err error) {
+	user, err := mysql.GetUserByID(userID)
+	if err != nil {
+		return
+	}
+	return changeRole(user, role)
+}
+
+// changeRole 角色发生变化时更新角色，并吊销用户所有Token使新角色生效
+func changeRole(user *models.User, role string) (err error) {
+	if user.Role == role {
+		return nil
+	}
+	if err = mysql.SetUserRole(user.UserID, role); err != nil {
+		return
+	}
+	return redis.RevokeUserTokens(user.UserID)
+}
+
+// CreateCommunity 创建社区
+func CreateCommunity(p *models.ParamCreateCommunity) (*models.CommunityDetailRes, error) {
+	communityID, err := mysql.CreateCommunity(p)
+	if err != nil {
+		return nil, err
+	}
+	return mysql.GetCommunityByID(communityID)
+}
+
+// AddCommunityModerator 指派社区版主，普通用户同时升级为版主角色
+func AddCommunityModerator(communityID uint64, p *models.ParamModerator) (err error) {
+	if _, err = mysql.GetCommunityByID(communityID); err != nil {
+		if err.Error() == mysql.ErrorInvalidID {
+			return ErrorCommunityNotExist
+		}
+		return
+	}
+	user, err := mysql.GetUserByID(p.UserID)
+	if err != nil {
+		return
+	}
+	if err = mysql.AddCommunityModerator(communityID, user.UserID); err != nil {
+		return
+	}
+	if user.Role == models.RoleUser {
+		return changeRole(user, models.RoleModerator)
+	}
+	return nil
+}
+
+// RemoveCommunityModerator 撤销社区版主，不再管理任何社区的版主降级为普通用户
+func RemoveCommunityModerator(communityID, userID uint64) (err error) {
+	user, err := mysql.GetUserByID(userID)
+	if err != nil {
+		return
+	}
+	if err = mysql.RemoveCommunityModerator(communityID, userID); err != nil {
+		return
+	}
+	if user.Role != models.RoleModerator {
+		return nil
+	}
+	ids, err := mysql.GetModeratedCommunityIDs(userID)
+	if err != nil {
+		return
+	}
+	if len(ids) == 0 {
+		return changeRole(user, models.RoleUser)
+	}
+	return nil
+}
+
+// RemovePost 版主删除所管理社区中的帖子
+func RemovePost(communityID, postID uint64) (err error) {
+	postCommunityID, err := mysql.GetPostCommunityID(postID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrorPostNotExist
+		}
+		return
+	}
+	// 只能删除当前社区下的帖子
+	if postCommunityID != communityID {
+		return ErrorPostNotExist
+	}
+	return removePost(postID, communityID)
+}
+
+// RemoveComment 版主删除所管理社区中的评论
+func RemoveComment(communityID, commentID uint64) (err error) {
+	comment, err := mysql.GetCommentByID(commentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrorCommentNotExist
+		}
+		return
+	}
+	postCommunityID, err := mysql.GetPostCommunityID(comment.PostID)
+	if err != nil && err != sql.ErrNoRows {
+		return
+	}
+	if postCommunityID != communityID {
+		return ErrorCommentNotExist
+	}
+	return removeComment(comment)
+}
+
+// SetPostFlags 版主设置所管理社区中帖子的置顶、锁定、精华状态
+func SetPostFlags(communityID, postID uint64, p *models.ParamPostFlags) (err error) {
+	post, err := mysql.GetPostByID(int64(postID))
+	if err != nil {
+		if err.Error() == mysql.ErrorInvalidID {
+			return ErrorPostNotExist
+		}
+		return
+	}
+	if post.CommunityID != communityID {
+		return ErrorPostNotExist
+	}
+	ok, err := mysql.SetPostFlags(communityID, postID, p, maxPinnedPosts)
+	if err != nil {
+		return
+	}
+	if !ok {
+		return ErrorTooManyPinned
+	}
+	pin := p.Pinned != nil && *p.Pinned && !post.Pinned
+	switch {
+	case pin:
+		return redis.PinPost(postID, communityID)
+	case p.Pinned != nil && !*p.Pinned && post.Pinned:
+		return redis.UnpinPost(postID, communityID)
+	}
+	return nil
+}
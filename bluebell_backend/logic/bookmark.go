@@ -0,0 +1,163 @@
+package logic
+
+import (
+	"bluebell_backend/dao/mysql"
+	"bluebell_backend/dao/redis"
+	"bluebell_backend/models"
+	"bluebell_backend/pkg/snowflake"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+/*
+收藏帖子
+	* 只能收藏已发布的帖子，重复收藏时只修改所属的收藏夹
+	* 收藏列表按收藏时间降序排列，优先读取redis中的缓存，缓存不存在时从MySQL重建
+	* 收藏夹：收藏时可以放入用户自己的收藏夹，删除收藏夹后其中的帖子仍保留在收藏列表中
+*/
+
+// AddBookmark 收藏帖子，可以指定收藏夹
+func AddBookmark(userID, postID uint64, p *models.ParamBookmark) (err error) {
+	if _, err = mysql.GetPostByID(int64(postID)); err != nil {
+		if err.Error() == mysql.ErrorInvalidID {
+			return ErrorPostNotExist
+		}
+		return
+	}
+	if p.CollectionID != 0 {
+		exists, err := mysql.CollectionExists(userID, p.CollectionID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrorCollectionNotExist
+		}
+	}
+	if err = mysql.AddBookmark(userID, postID, p.CollectionID); err != nil {
+		return
+	}
+	return redis.InvalidateBookmarks(userID)
+}
+
+// RemoveBookmark 取消收藏帖子
+func RemoveBookmark(userID, postID uint64) (err error) {
+	if err = mysql.RemoveBookmark(userID, postID); err != nil {
+		return
+	}
+	return redis.InvalidateBookmarks(userID)
+}
+
+// GetBookmarkList 按收藏时间降序分页查询用户收藏的帖子，可以只查询某个收藏夹
+func GetBookmarkList(userID uint64, p *models.ParamBookmarkList) (*models.ApiPostDetailRes, error) {
+	var (
+		ids   []string
+		total int64
+		err   error
+	)
+	if p.CollectionID != 0 {
+		exists, err := mysql.CollectionExists(userID, p.CollectionID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrorCollectionNotExist
+		}
+		ids, total, err = mysql.GetCollectionBookmarkIDs(userID, p.CollectionID, p.Page, p.Size)
+		if err != nil {
+			return nil, err
+		}
+	} else if ids, total, err = getBookmarkIDs(userID, p.Page, p.Size); err != nil {
+		return nil, err
+	}
+
+	var res models.ApiPostDetailRes
+	res.Page.Page = p.Page
+	res.Page.Size = p.Size
+	res.Page.Total = total
+	// 已删除的帖子不会出现在列表中
+	if res.List, err = getPostDetailsByIDs(ids); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// getBookmarkIDs 分页查询用户收藏的帖子ids，redis缓存不存在时从MySQL重建
+func getBookmarkIDs(userID uint64, page, size int64) (ids []string, total int64, err error) {
+	ids, total, cached, err := redis.GetBookmarkIDs(userID, page, size)
+	if err != nil || cached {
+		return
+	}
+	version, err := redis.GetBookmarkVersion(userID)
+	if err != nil {
+		return
+	}
+	bookmarks, err := mysql.GetBookmarks(userID)
+	if err != nil {
+		return
+	}
+	if err := redis.SetBookmarks(userID, version, bookmarks); err != nil {
+		zap.L().Error("redis.SetBookmarks failed", zap.Uint64("user_id", userID), zap.Error(err))
+	}
+	total = int64(len(bookmarks))
+	ids = make([]string, 0, size)
+	for i := (page - 1) * size; i >= 0 && i < total && i < page*size; i++ {
+		ids = append(ids, strconv.FormatUint(bookmarks[i].PostID, 10))
+	}
+	return ids, total, nil
+}
+
+// IsBookmarked 查询用户是否收藏了帖子，查询失败时按未收藏处理
+func IsBookmarked(userID, postID uint64) bool {
+	bookmarked, cached, err := redis.IsBookmarked(userID, postID)
+	if err == nil && !cached {
+		bookmarked, err = mysql.IsBookmarked(userID, postID)
+	}
+	if err != nil {
+		zap.L().Error("IsBookmarked failed",
+			zap.Uint64("user_id", userID),
+			zap.Uint64("post_id", postID),
+			zap.Error(err))
+		return false
+	}
+	return bookmarked
+}
+
+// CreateCollection 创建收藏夹
+func CreateCollection(userID uint64, p *models.ParamCollection) (*models.BookmarkCollection, error) {
+	collectionID, err := snowflake.GetID()
+	if err != nil {
+		return nil, err
+	}
+	collection := &models.BookmarkCollection{
+		CollectionID: collectionID,
+		UserID:       userID,
+		Name:         p.Name,
+		CreateTime:   time.Now(),
+	}
+	if err = mysql.CreateCollection(collection); err != nil {
+		if err == mysql.ErrorCollectionExist {
+			return nil, ErrorCollectionExist
+		}
+		return nil, err
+	}
+	return collection, nil
+}
+
+// GetCollections 查询用户的收藏夹列表
+func GetCollections(userID uint64) ([]*models.BookmarkCollection, error) {
+	return mysql.GetCollections(userID)
+}
+
+// DeleteCollection 删除收藏夹
+func DeleteCollection(userID, collectionID uint64) error {
+	deleted, err := mysql.DeleteCollection(userID, collectionID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrorCollectionNotExist
+	}
+	return nil
+}
@@ -0,0 +1,358 @@
+package logic
+
+import (
+	"bluebell_backend/dao/mysql"
+	"bluebell_backend/dao/redis"
+	"bluebell_backend/models"
+	"bluebell_backend/pkg/jwt"
+	"bluebell_backend/pkg/markdown"
+	"bluebell_backend/pkg/snowflake"
+	"bluebell_backend/settings"
+	"database/sql"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+/*
+评论树
+	* 评论通过parent_id组成树，一级评论的parent_id为0
+	* 分页查询一级评论：按发布时间(MySQL)、得分或Wilson得分(redis中每个帖子的一级评论ZSet)排序；回复按发布时间升序排列
+	* 第一次按得分排序查询帖子的评论时，将MySQL中尚未加入ZSet的一级评论补充到ZSet中
+	* 评论投票：赞成票/反对票数量及用户的投票保存在redis中，一级评论每票VoteScore分；
+	  best排序使用赞成率的Wilson置信区间下界，票数少的评论不会因偶然的高赞成率排在前面
+	* 每次最多返回comment_max_depth层，每条评论最多返回commentRepliesPerNode条回复，总数不超过commentTreeMaxNodes；
+	  未返回的回复被折叠，只返回数量，客户端通过parent_id继续加载
+	* 评论作者、帖子所属社区的版主和管理员可以编辑和删除评论；删除为软删除，有回复的评论显示为[deleted]并保留回复
+*/
+
+const (
+	defaultCommentMaxDepth = 4
+	commentRepliesPerNode  = 10          // 评论树中每条评论最多返回的直接回复数，其余回复通过parent_id分页加载
+	commentTreeMaxNodes    = 500         // 一次最多返回的评论数(包括回复)
+	deletedCommentContent  = "[deleted]" // 已删除评论显示的内容
+)
+
+// CreateComment 发表评论：帖子必须存在且未锁定，回复的评论必须属于同一帖子
+func CreateComment(userID uint64, p *models.ParamComment) (comment *models.Comment, err error) {
+	content := strings.TrimSpace(p.Content)
+	if content == "" {
+		return nil, ErrorEmptyComment
+	}
+	if err = checkPostUnlocked(p.PostID); err != nil {
+		return
+	}
+	if p.ParentID != 0 {
+		parent, err := mysql.GetCommentByID(p.ParentID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, ErrorParentCommentNotExist
+			}
+			return nil, err
+		}
+		if parent.PostID != p.PostID {
+			return nil, ErrorParentCommentNotExist
+		}
+	}
+	commentID, err := snowflake.GetID()
+	if err != nil {
+		return
+	}
+	comment = &models.Comment{
+		PostID:     p.PostID,
+		ParentID:   p.ParentID,
+		CommentID:  commentID,
+		AuthorID:   userID,
+		Content:    content,
+		Status:     models.CommentStatusNormal,
+		CreateTime: time.Now(),
+	}
+	if err = mysql.CreateComment(comment); err != nil {
+		return nil, err
+	}
+	if comment.ParentID == 0 {
+		if err = redis.CreateComment(comment.PostID, comment.CommentID, comment.CreateTime); err != nil {
+			return nil, err
+		}
+	}
+	incrPostCommentNum(comment.PostID, 1)
+	dispatchNotifications(comment)
+	comment.ContentHTML = markdown.Render(comment.Content)
+	return comment, nil
+}
+
+// getCommentForManage 查询评论并校验当前用户是否可以管理：评论作者、帖子所属社区的版主或管理员
+func getCommentForManage(mc *jwt.MyClaims, commentID uint64) (comment *models.Comment, moderator bool, err error) {
+	comment, err = mysql.GetCommentByID(commentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, false, ErrorCommentNotExist
+		}
+		return
+	}
+	communityID, err := mysql.GetPostCommunityID(comment.PostID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, false, ErrorCommentNotExist
+		}
+		return
+	}
+	if moderator, err = canModerate(mc, communityID); err != nil {
+		return
+	}
+	if !moderator && comment.AuthorID != mc.UserID {
+		return nil, false, ErrorNoPermission
+	}
+	return comment, moderator, nil
+}
+
+// UpdateComment 编辑评论，锁定的帖子中只有版主和管理员可以编辑
+func UpdateComment(mc *jwt.MyClaims, commentID uint64, p *models.ParamUpdateComment) (*models.Comment, error) {
+	content := strings.TrimSpace(p.Content)
+	if content == "" {
+		return nil, ErrorEmptyComment
+	}
+	comment, moderator, err := getCommentForManage(mc, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if !moderator {
+		if err = checkPostUnlocked(comment.PostID); err != nil {
+			return nil, err
+		}
+	}
+	if err = mysql.UpdateComment(commentID, content); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	comment.Content = content
+	comment.EditTime = &now
+	comment.ContentHTML = markdown.Render(comment.Content)
+	return comment, nil
+}
+
+// DeleteComment 删除评论
+func DeleteComment(mc *jwt.MyClaims, commentID uint64) error {
+	comment, _, err := getCommentForManage(mc, commentID)
+	if err != nil {
+		return err
+	}
+	return removeComment(comment)
+}
+
+// removeComment 软删除评论：有回复的评论保留在评论树中显示为[deleted]，没有回复的评论不再显示
+func removeComment(comment *models.Comment) (err error) {
+	removed, err := mysql.RemoveComment(comment.CommentID)
+	if err != nil || !removed {
+		// 评论已被并发删除，评论数和Redis已由删除成功的请求处理
+		return
+	}
+	incrPostCommentNum(comment.PostID, -1)
+	hasReplies, err := mysql.HasReplies(comment.PostID, comment.CommentID)
+	if err != nil || hasReplies {
+		return
+	}
+	return redis.RemoveComment(comment.PostID, comment.CommentID)
+}
+
+// commentMaxDepth 评论树最多返回的层数
+func commentMaxDepth() int {
+	if settings.Conf.CommentMaxDepth < 1 {
+		return defaultCommentMaxDepth
+	}
+	return settings.Conf.CommentMaxDepth
+}
+
+// GetCommentTree 分页查询帖子的评论树，指定parent_id时查询该评论的回复；userID不为0时返回该用户的投票
+func GetCommentTree(postID, userID uint64, p *models.ParamCommentTree) (*models.ApiCommentTree, error) {
+	if _, err := mysql.GetPostByID(int64(postID)); err != nil {
+		if err.Error() == mysql.ErrorInvalidID {
+			return nil, ErrorPostNotExist
+		}
+		return nil, err
+	}
+	if p.ParentID != 0 {
+		parent, err := mysql.GetCommentByID(p.ParentID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, ErrorCommentNotExist
+			}
+			return nil, err
+		}
+		if parent.PostID != postID {
+			return nil, ErrorCommentNotExist
+		}
+	}
+	depth := commentMaxDepth()
+	if p.Depth > 0 && p.Depth < depth {
+		depth = p.Depth
+	}
+
+	// 1.分页查询一级评论(或指定评论的回复)ids
+	var (
+		ids   []string
+		total int64
+		err   error
+	)
+	if p.ParentID == 0 && p.Order != models.OrderTime {
+		if err = ensureCommentsIndexed(postID); err != nil {
+			return nil, err
+		}
+		ids, total, err = redis.GetCommentIDsInOrder(postID, p.Order, p.Page, p.Size)
+	} else {
+		ids, total, err = mysql.GetReplyIDs(postID, p.ParentID, p.Page, p.Size)
+	}
+	if err != nil {
+		return nil, err
+	}
+	res := &models.ApiCommentTree{List: make([]*models.ApiCommentNode, 0, len(ids))}
+	res.Page.Page = p.Page
+	res.Page.Size = p.Size
+	res.Page.Total = total
+	if len(ids) == 0 {
+		return res, nil
+	}
+	comments, err := mysql.GetCommentTreeByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*models.Comment, len(comments))
+	for _, comment := range comments {
+		byID[strconv.FormatUint(comment.CommentID, 10)] = comment
+	}
+	for _, id := range ids { // 按ids的顺序返回，已删除的评论不会从数据库中查出
+		if comment, ok := byID[id]; ok {
+			res.List = append(res.List, &models.ApiCommentNode{Comment: comment, Replies: []*models.ApiCommentNode{}})
+		}
+	}
+
+	// 2.逐层查询回复：每条评论最多返回commentRepliesPerNode条回复，总数不超过commentTreeMaxNodes，
+	// 最后一层及超出数量上限的回复只统计数量
+	nodes := res.List // 所有节点，用于填充作者和内容
+	level := res.List
+	for i := 1; len(level) > 0; i++ {
+		parents := make(map[uint64]*models.ApiCommentNode, len(level))
+		parentIDs := make([]uint64, 0, len(level))
+		for _, node := range level {
+			parents[node.CommentID] = node
+			parentIDs = append(parentIDs, node.CommentID)
+		}
+		counts, err := mysql.CountRepliesByParentIDs(postID, parentIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range level {
+			node.ReplyNum = counts[node.CommentID]
+			node.CollapsedNum = node.ReplyNum
+		}
+		remaining := commentTreeMaxNodes - len(nodes)
+		if i == depth || remaining <= 0 {
+			break
+		}
+		replies, err := mysql.GetRepliesByParentIDs(postID, parentIDs, commentRepliesPerNode, remaining)
+		if err != nil {
+			return nil, err
+		}
+		level = make([]*models.ApiCommentNode, 0, len(replies))
+		for _, reply := range replies {
+			node := &models.ApiCommentNode{Comment: reply, Replies: []*models.ApiCommentNode{}}
+			parent := parents[reply.ParentID]
+			parent.Replies = append(parent.Replies, node)
+			parent.CollapsedNum--
+			level = append(level, node)
+		}
+		nodes = append(nodes, level...)
+	}
+
+	// 3.填充作者名称、投票并渲染评论内容，best排序时按Wilson得分排列回复
+	fillCommentNodes(nodes)
+	fillCommentVotes(postID, userID, nodes)
+	if p.Order == models.OrderBest {
+		for _, node := range nodes {
+			sortRepliesByBest(node.Replies)
+		}
+	}
+	return res, nil
+}
+
+// ensureCommentsIndexed 帖子的一级评论尚未全部加入redis得分ZSet时从MySQL补充，
+// 得分排序上线前发表的评论只保存在MySQL中
+func ensureCommentsIndexed(postID uint64) error {
+	indexed, err := redis.IsCommentIndexed(postID)
+	if err != nil || indexed {
+		return err
+	}
+	comments, err := mysql.GetTopCommentTimes(postID)
+	if err != nil {
+		return err
+	}
+	return redis.IndexComments(postID, comments)
+}
+
+// fillCommentNodes 批量查询评论作者名称，并将评论内容渲染为HTML；已删除的评论隐藏作者和内容
+func fillCommentNodes(nodes []*models.ApiCommentNode) {
+	authorIDs := make([]uint64, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Status == models.CommentStatusDeleted {
+			node.Deleted = true
+			node.AuthorID = 0
+			node.Content = deletedCommentContent
+			continue
+		}
+		authorIDs = append(authorIDs, node.AuthorID)
+	}
+	names, err := mysql.GetUserNamesByIDs(authorIDs)
+	if err != nil {
+		zap.L().Error("mysql.GetUserNamesByIDs failed", zap.Error(err))
+	}
+	for _, node := range nodes {
+		if node.Deleted {
+			continue
+		}
+		node.AuthorName = names[node.AuthorID]
+		node.ContentHTML = markdown.Render(node.Content)
+	}
+}
+
+// fillCommentVotes 批量查询评论的赞成票/反对票数量及用户的投票
+func fillCommentVotes(postID, userID uint64, nodes []*models.ApiCommentNode) {
+	ids := make([]uint64, 0, len(nodes))
+	for _, node := range nodes {
+		ids = append(ids, node.CommentID)
+	}
+	ups, downs, voted, err := redis.GetCommentVotes(postID, userID, ids)
+	if err != nil {
+		zap.L().Error("redis.GetCommentVotes failed", zap.Uint64("post_id", postID), zap.Error(err))
+		return
+	}
+	for _, node := range nodes {
+		node.Ups = ups[node.CommentID]
+		node.Downs = downs[node.CommentID]
+		node.Vote = voted[node.CommentID]
+	}
+}
+
+// sortRepliesByBest 按Wilson得分降序排列回复，得分相同时保持发布时间顺序
+func sortRepliesByBest(replies []*models.ApiCommentNode) {
+	sort.SliceStable(replies, func(i, j int) bool {
+		return redis.WilsonScore(replies[i].Ups, replies[i].Downs) > redis.WilsonScore(replies[j].Ups, replies[j].Downs)
+	})
+}
+
+// VoteComment 为评论投票，已删除的评论和锁定帖子中的评论不允许投票
+func VoteComment(userID, commentID uint64, p *models.ParamCommentVote) error {
+	comment, err := mysql.GetCommentByID(commentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrorCommentNotExist
+		}
+		return err
+	}
+	if err = checkPostUnlocked(comment.PostID); err != nil {
+		return err
+	}
+	return redis.VoteForComment(userID, comment.PostID, commentID, p.Direction)
+}
@@ -0,0 +1,103 @@
+package logic
+
+import (
+	"bluebell_backend/dao/mysql"
+	"bluebell_backend/dao/redis"
+	"bluebell_backend/models"
+	"bluebell_backend/settings"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+/*
+帖子评论数
+	* 评论数保存在redis帖子Hash的comments字段，同时写入评论数ZSet，用于按评论数(讨论最多)排序
+	* 发表评论时加1，删除评论时减1；修改失败时只记录日志，由后台任务校正
+	* 后台任务定时按MySQL中未删除的评论重新统计每篇已发布帖子的评论数
+*/
+
+const (
+	defaultCommentReconcileInterval = time.Hour
+	commentReconcileBatchSize       = 500 // 每次从MySQL统计的帖子数
+)
+
+// incrPostCommentNum 修改帖子的评论数，失败时只记录日志
+func incrPostCommentNum(postID uint64, delta int64) {
+	if err := redis.IncrPostCommentNum(postID, delta); err != nil {
+		zap.L().Error("redis.IncrPostCommentNum failed",
+			zap.Uint64("post_id", postID),
+			zap.Int64("delta", delta),
+			zap.Error(err))
+	}
+}
+
+// fillPostCommentNum 批量查询并填充帖子的评论数
+func fillPostCommentNum(list []*models.ApiPostDetail) {
+	if len(list) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(list))
+	for _, post := range list {
+		ids = append(ids, strconv.FormatUint(post.PostID, 10))
+	}
+	nums, err := redis.GetPostCommentNums(ids)
+	if err != nil {
+		zap.L().Error("redis.GetPostCommentNums failed", zap.Error(err))
+		return
+	}
+	for idx, post := range list {
+		post.CommentNum = nums[idx]
+	}
+}
+
+// reconcileCommentNums 按MySQL中的评论重新统计所有已发布帖子的评论数
+func reconcileCommentNums(interval time.Duration) (err error) {
+	token, err := randomToken()
+	if err != nil {
+		return
+	}
+	// 多个实例同时运行时只有获得锁的实例校正
+	locked, err := redis.TryLock(redis.KeyCommentReconcileLock, token, interval)
+	if err != nil || !locked {
+		return
+	}
+	defer func() {
+		if err := redis.Unlock(redis.KeyCommentReconcileLock, token); err != nil {
+			zap.L().Warn("redis.Unlock failed", zap.Error(err))
+		}
+	}()
+
+	var afterID uint64
+	for {
+		nums, err := mysql.GetPostCommentNums(afterID, commentReconcileBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, n := range nums {
+			if err := redis.SetPostCommentNum(n.PostID, n.CommentNum); err != nil {
+				zap.L().Error("redis.SetPostCommentNum failed", zap.Uint64("post_id", n.PostID), zap.Error(err))
+			}
+			afterID = n.PostID
+		}
+		if len(nums) < commentReconcileBatchSize {
+			return nil
+		}
+	}
+}
+
+// StartCommentReconciler 启动校正帖子评论数的后台任务
+func StartCommentReconciler() {
+	interval := time.Duration(settings.Conf.CommentReconcileInterval) * time.Minute
+	if interval <= 0 {
+		interval = defaultCommentReconcileInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := reconcileCommentNums(interval); err != nil {
+			zap.L().Error("reconcileCommentNums failed", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package logic
+
+import (
+	"bluebell_backend/models"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 帖子版本比较：按行或按词切分后使用Myers算法计算最短编辑序列
+
+// maxDiffEdits 编辑距离上限，超过时不再逐词比较，直接整段替换，避免差异过大时耗费过多内存
+const maxDiffEdits = 1000
+
+// DiffLines 按行比较两段文本
+func DiffLines(a, b string) []*models.DiffOp {
+	return diffTokens(splitLines(a), splitLines(b))
+}
+
+// DiffWords 按词比较两段文本，中日韩文字每个字单独作为一个词
+func DiffWords(a, b string) []*models.DiffOp {
+	return diffTokens(splitWords(a), splitWords(b))
+}
+
+// splitLines 按行切分，保留每行末尾的换行符
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// splitWords 按词切分：连续的字母数字作为一个词，中日韩文字和分隔符每个字符单独作为一个词
+func splitWords(s string) []string {
+	tokens := make([]string, 0, len(s)/2)
+	start := -1 // 当前单词的起始位置
+	for i := 0; i < len(s); {
+		r, width := utf8.DecodeRuneInString(s[i:])
+		if !isSeparator(r) && !isCJK(r) {
+			if start < 0 {
+				start = i
+			}
+			i += width
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, s[start:i])
+			start = -1
+		}
+		tokens = append(tokens, s[i:i+width])
+		i += width
+	}
+	if start >= 0 {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
+
+// isCJK 判断是否为中日韩文字，这些文字之间没有空格分隔
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// diffBuilder 合并相邻的同类差异片段
+type diffBuilder struct {
+	ops []*models.DiffOp
+}
+
+func (b *diffBuilder) add(op string, tokens ...string) {
+	if len(tokens) == 0 {
+		return
+	}
+	text := strings.Join(tokens, "")
+	if n := len(b.ops); n > 0 && b.ops[n-1].Op == op {
+		b.ops[n-1].Text += text
+		return
+	}
+	b.ops = append(b.ops, &models.DiffOp{Op: op, Text: text})
+}
+
+// diffTokens 比较两个词序列，先去掉相同的前缀和后缀缩小比较范围
+func diffTokens(a, b []string) []*models.DiffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	builder := &diffBuilder{ops: make([]*models.DiffOp, 0)}
+	builder.add(models.DiffEqual, a[:prefix]...)
+	myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], builder)
+	builder.add(models.DiffEqual, a[len(a)-suffix:]...)
+	return builder.ops
+}
+
+// myers Myers差分算法，求a到b的最短编辑序列
+func myers(a, b []string, builder *diffBuilder) {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		builder.add(models.DiffDelete, a...)
+		builder.add(models.DiffInsert, b...)
+		return
+	}
+	limit := n + m
+	if limit > maxDiffEdits {
+		limit = maxDiffEdits
+	}
+	offset := limit + 1
+	v := make([]int, 2*limit+3) // v[offset+k] 第k条对角线上能到达的最远x
+	// trace[d] 保存第d步之前对角线[-d-1, d+1]的状态，用于回溯
+	trace := make([][]int, 0, 16)
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // 从k+1对角线向下移动：插入
+			} else {
+				x = v[offset+k-1] + 1 // 从k-1对角线向右移动：删除
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				backtrack(a, b, trace, builder)
+				return
+			}
+		}
+	}
+	// 差异过大，整段替换
+	builder.add(models.DiffDelete, a...)
+	builder.add(models.DiffInsert, b...)
+}
+
+// backtrack 从终点回溯编辑路径
+func backtrack(a, b []string, trace [][]int, builder *diffBuilder) {
+	type edit struct {
+		op    string
+		token string
+	}
+	edits := make([]edit, 0, len(a)+len(b))
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			edits = append(edits, edit{models.DiffEqual, a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, edit{models.DiffInsert, b[y-1]})
+			} else {
+				edits = append(edits, edit{models.DiffDelete, a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	for i := len(edits) - 1; i >= 0; i-- {
+		builder.add(edits[i].op, edits[i].token)
+	}
+}
@@ -0,0 +1,93 @@
+package logic
+
+import (
+	"bluebell_backend/dao/mysql"
+	"bluebell_backend/dao/redis"
+	"bluebell_backend/models"
+	"bluebell_backend/pkg/rabbitmq"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// EmailVerifyTokenExpire 邮箱验证链接的有效期
+const EmailVerifyTokenExpire = 24 * time.Hour
+
+// randomToken 生成随机的一次性令牌，用于邮件中的验证链接
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// sendVerifyEmail 生成邮箱验证令牌，并将验证邮件任务异步发布到RabbitMQ队列中
+func sendVerifyEmail(userID uint64, username, email string) error {
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	if err := redis.SaveEmailVerifyToken(token, userID, email, EmailVerifyTokenExpire); err != nil {
+		return err
+	}
+	Ed := &models.RegisterEmailData{
+		Type:     models.EmailTypeVerify,
+		Email:    email,
+		UserName: username,
+		Token:    token,
+	}
+	zap.L().Debug("emaildetail", zap.String("Username", Ed.UserName),
+		zap.String("Email", Ed.Email))
+	// 使用生产者发布邮件任务到队列
+	if err := rabbitmq.PublishEmailTask(Ed); err != nil {
+		return fmt.Errorf("failed to publish email task: %w", err)
+	}
+	return nil
+}
+
+// VerifyEmail 校验邮箱验证令牌，令牌只能使用一次
+func VerifyEmail(token string) error {
+	userID, email, err := redis.TakeEmailVerifyToken(token)
+	if err != nil {
+		if err == redis.Nil {
+			return ErrorInvalidVerifyToken
+		}
+		return err
+	}
+	// 用户在此期间修改了邮箱，旧邮箱收到的链接不能验证新邮箱
+	ok, err := mysql.SetEmailVerified(userID, email)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+	// 邮箱已验证过时update不修改任何行，此时链接仍视为有效
+	user, err := mysql.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.Email != email || !user.EmailVerified {
+		return ErrorInvalidVerifyToken
+	}
+	return nil
+}
+
+// ResendVerifyEmail 重新发送验证邮件
+func ResendVerifyEmail(userID uint64) error {
+	user, err := mysql.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return ErrorEmailVerified
+	}
+	if user.Email == "" {
+		return ErrorEmailMissing
+	}
+	return sendVerifyEmail(user.UserID, user.UserName, user.Email)
+}
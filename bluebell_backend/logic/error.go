@@ -0,0 +1,49 @@
+package logic
+
+import "errors"
+
+var (
+	ErrorInvalidToken       = errors.New("无效的Token")
+	ErrorTokenRevoked       = errors.New("Token已被吊销")
+	ErrorRefreshTokenReused = errors.New("refresh_token重复使用")
+
+	ErrorInvalidVerifyToken = errors.New("验证链接无效或已过期")
+	ErrorEmailVerified      = errors.New("邮箱已验证")
+	ErrorEmailMissing       = errors.New("未填写邮箱")
+
+	ErrorPasswordWrong     = errors.New("密码错误")
+	ErrorInvalidResetToken = errors.New("重置链接无效或已过期")
+
+	ErrorCommunityNotExist     = errors.New("社区不存在")
+	ErrorPostNotExist          = errors.New("帖子不存在")
+	ErrorCommentNotExist       = errors.New("评论不存在")
+	ErrorEmptyComment          = errors.New("评论内容不能为空")
+	ErrorParentCommentNotExist = errors.New("回复的评论不存在")
+	ErrorNoPermission          = errors.New("没有权限")
+	ErrorRevisionNotExist      = errors.New("帖子版本不存在")
+	ErrorPostPublished         = errors.New("帖子已发布")
+	ErrorPostLocked            = errors.New("帖子已锁定")
+	ErrorTooManyPinned         = errors.New("置顶帖子数量已达上限")
+
+	ErrorCollectionNotExist = errors.New("收藏夹不存在")
+	ErrorCollectionExist    = errors.New("收藏夹已存在")
+
+	ErrorNotificationNotExist = errors.New("通知不存在")
+
+	ErrorInvalidPoll       = errors.New("投票需要2~10个选项，截止时间需晚于发布时间")
+	ErrorPollNotExist      = errors.New("投票不存在")
+	ErrorPollClosed        = errors.New("投票已截止")
+	ErrorPollVoted         = errors.New("已参与过该投票")
+	ErrorInvalidPollOption = errors.New("无效的投票选项")
+	ErrorInvalidTag        = errors.New("标签不合法")
+	ErrorTooManyTags       = errors.New("标签数量超过上限")
+
+	ErrorFileTooLarge      = errors.New("文件过大")
+	ErrorFileType          = errors.New("不支持的文件类型")
+	ErrorInvalidAttachment = errors.New("附件不存在或已被其他帖子使用")
+
+	ErrorTwoFactorEnabled      = errors.New("已开启两步验证")
+	ErrorTwoFactorNotEnabled   = errors.New("未开启两步验证")
+	ErrorInvalidTwoFactorCode  = errors.New("验证码错误")
+	ErrorInvalidTwoFactorToken = errors.New("登录验证已过期")
+)
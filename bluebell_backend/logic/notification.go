@@ -0,0 +1,208 @@
+package logic
+
+import (
+	"bluebell_backend/dao/mysql"
+	"bluebell_backend/dao/redis"
+	"bluebell_backend/models"
+	"bluebell_backend/pkg/rabbitmq"
+	"bluebell_backend/pkg/snowflake"
+	"database/sql"
+	"regexp"
+
+	"go.uber.org/zap"
+)
+
+/*
+回复与@通知
+	* 发表评论后将通知任务发布到RabbitMQ，由消费者异步生成通知；发布失败时在当前实例异步处理
+	* 一级评论通知帖子作者，回复通知被回复评论的作者，评论中@的用户收到mention通知
+	* 同一评论对同一用户只通知一次(notification表唯一索引)，不通知评论作者自己，任务重复投递不会重复通知
+	* 未读通知数量缓存在redis中，缓存不存在时从MySQL统计
+*/
+
+const maxMentions = 10 // 每条评论最多通知的@用户数
+
+// mentionPattern 匹配评论中的@用户名，@前面不能是用户名字符，避免把邮箱地址当作@
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.-])@([\p{L}\p{N}_.-]{1,32})`)
+
+// dispatchNotifications 发布评论的通知任务
+func dispatchNotifications(comment *models.Comment) {
+	task := &models.NotificationTask{
+		PostID:    comment.PostID,
+		CommentID: comment.CommentID,
+		ParentID:  comment.ParentID,
+		ActorID:   comment.AuthorID,
+		Content:   comment.Content,
+	}
+	if err := rabbitmq.PublishNotificationTask(task); err != nil {
+		zap.L().Error("rabbitmq.PublishNotificationTask failed",
+			zap.Uint64("comment_id", comment.CommentID),
+			zap.Error(err))
+		go func() {
+			if err := DeliverNotifications(task); err != nil {
+				zap.L().Error("DeliverNotifications failed",
+					zap.Uint64("comment_id", task.CommentID),
+					zap.Error(err))
+			}
+		}()
+	}
+}
+
+// parseMentions 解析评论中@的用户名，去重后最多返回maxMentions个
+func parseMentions(content string) []string {
+	matches := mentionPattern.FindAllStringSubmatch(content, -1)
+	names := make([]string, 0, len(matches))
+	seen := make(map[string]struct{}, len(matches))
+	for _, m := range matches {
+		if _, ok := seen[m[1]]; ok {
+			continue
+		}
+		seen[m[1]] = struct{}{}
+		names = append(names, m[1])
+		if len(names) == maxMentions {
+			break
+		}
+	}
+	return names
+}
+
+// DeliverNotifications 处理评论的通知任务：生成回复和@通知，并增加接收者的未读通知数量
+func DeliverNotifications(task *models.NotificationTask) (err error) {
+	// 接收者及通知类型，按回复、@的顺序，每个用户只通知一次
+	targets := make([]uint64, 0, 2)
+	types := make(map[uint64]string, 2)
+	addTarget := func(userID uint64, typ string) {
+		if userID == 0 || userID == task.ActorID {
+			return
+		}
+		if _, ok := types[userID]; ok {
+			return
+		}
+		targets = append(targets, userID)
+		types[userID] = typ
+	}
+
+	if task.ParentID == 0 {
+		post, err := mysql.GetPostByID(int64(task.PostID))
+		if err != nil && err.Error() != mysql.ErrorInvalidID {
+			return err
+		}
+		// 帖子已删除时不再通知
+		if err == nil {
+			addTarget(post.AuthorId, models.NotificationReplyPost)
+		}
+	} else {
+		parent, err := mysql.GetCommentByID(task.ParentID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil {
+			addTarget(parent.AuthorID, models.NotificationReplyComment)
+		}
+	}
+	if names := parseMentions(task.Content); len(names) > 0 {
+		ids, err := mysql.GetUserIDsByNames(names)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			addTarget(ids[name], models.NotificationMention)
+		}
+	}
+
+	for _, userID := range targets {
+		notificationID, err := snowflake.GetID()
+		if err != nil {
+			return err
+		}
+		inserted, err := mysql.CreateNotification(&models.Notification{
+			NotificationID: notificationID,
+			UserID:         userID,
+			Type:           types[userID],
+			ActorID:        task.ActorID,
+			PostID:         task.PostID,
+			CommentID:      task.CommentID,
+		})
+		if err != nil {
+			return err
+		}
+		if !inserted {
+			continue
+		}
+		if err := redis.IncrUnreadNotificationNum(userID, 1); err != nil {
+			zap.L().Error("redis.IncrUnreadNotificationNum failed",
+				zap.Uint64("user_id", userID),
+				zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// GetNotificationList 按时间降序分页查询用户的通知，同时返回未读通知数量
+func GetNotificationList(userID uint64, p *models.ParamNotificationList) (*models.ApiNotificationList, error) {
+	list, total, err := mysql.GetNotifications(userID, p.Unread, p.Page, p.Size)
+	if err != nil {
+		return nil, err
+	}
+	actorIDs := make([]uint64, 0, len(list))
+	for _, n := range list {
+		actorIDs = append(actorIDs, n.ActorID)
+	}
+	names, err := mysql.GetUserNamesByIDs(actorIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range list {
+		n.ActorName = names[n.ActorID]
+	}
+	unread, err := getUnreadNotificationNum(userID)
+	if err != nil {
+		return nil, err
+	}
+	res := &models.ApiNotificationList{
+		UnreadNum: unread,
+		List:      list,
+	}
+	res.Page.Page = p.Page
+	res.Page.Size = p.Size
+	res.Page.Total = total
+	return res, nil
+}
+
+// getUnreadNotificationNum 查询用户的未读通知数量，redis缓存不存在时从MySQL统计并写入缓存
+func getUnreadNotificationNum(userID uint64) (int64, error) {
+	n, cached, err := redis.GetUnreadNotificationNum(userID)
+	if err != nil || cached {
+		return n, err
+	}
+	if n, err = mysql.CountUnreadNotifications(userID); err != nil {
+		return 0, err
+	}
+	if err := redis.SetUnreadNotificationNum(userID, n); err != nil {
+		zap.L().Error("redis.SetUnreadNotificationNum failed", zap.Uint64("user_id", userID), zap.Error(err))
+	}
+	return n, nil
+}
+
+// MarkNotificationRead 将用户的一条通知标记为已读
+func MarkNotificationRead(userID, notificationID uint64) error {
+	exists, updated, err := mysql.MarkNotificationRead(userID, notificationID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrorNotificationNotExist
+	}
+	if updated {
+		return redis.IncrUnreadNotificationNum(userID, -1)
+	}
+	return nil
+}
+
+// MarkAllNotificationsRead 将用户的所有通知标记为已读
+func MarkAllNotificationsRead(userID uint64) error {
+	if err := mysql.MarkAllNotificationsRead(userID); err != nil {
+		return err
+	}
+	return redis.SetUnreadNotificationNum(userID, 0)
+}
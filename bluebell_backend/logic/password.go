@@ -0,0 +1,87 @@
+package logic
+
+import (
+	"bluebell_backend/dao/mysql"
+	"bluebell_backend/dao/redis"
+	"bluebell_backend/models"
+	"bluebell_backend/pkg/rabbitmq"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// PasswordResetTokenExpire 重置密码链接的有效期
+const PasswordResetTokenExpire = 30 * time.Minute
+
+// ChangePassword 修改密码：校验旧密码后更新，并注销用户所有设备
+func ChangePassword(userID uint64, p *models.ChangePasswordForm) (err error) {
+	if err = mysql.CheckUserPassword(userID, p.OldPassword); err != nil {
+		if err.Error() == mysql.ErrorPasswordWrong {
+			return ErrorPasswordWrong
+		}
+		return
+	}
+	return updatePassword(userID, p.NewPassword)
+}
+
+// ForgotPassword 忘记密码：向邮箱发送重置密码链接
+// 只发送给已验证该邮箱的账号，邮箱未注册或未验证时同样返回成功，避免通过该接口探测邮箱是否注册
+func ForgotPassword(p *models.ForgotPasswordForm) error {
+	users, err := mysql.GetVerifiedUsersByEmail(p.Email)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if err := sendResetPasswordEmail(user.UserID, user.UserName, user.Email); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResetPassword 使用邮件中的令牌重置密码，令牌只能使用一次
+func ResetPassword(p *models.ResetPasswordForm) error {
+	userID, err := redis.TakePasswordResetToken(p.Token)
+	if err != nil {
+		if err == redis.Nil {
+			return ErrorInvalidResetToken
+		}
+		return err
+	}
+	return updatePassword(userID, p.NewPassword)
+}
+
+// updatePassword 更新密码，并吊销用户所有的Token，已登录的设备需要使用新密码重新登录
+func updatePassword(userID uint64, newPassword string) error {
+	if err := mysql.UpdateUserPassword(userID, newPassword); err != nil {
+		return err
+	}
+	if err := redis.RevokeUserTokens(userID); err != nil {
+		// 密码已修改，Token吊销失败时旧Token仍可能有效，需要返回错误让用户重试
+		return fmt.Errorf("revoke user tokens failed: %w", err)
+	}
+	return nil
+}
+
+// sendResetPasswordEmail 生成重置密码令牌，并将邮件任务异步发布到RabbitMQ队列中
+func sendResetPasswordEmail(userID uint64, username, email string) error {
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	if err := redis.SavePasswordResetToken(token, userID, PasswordResetTokenExpire); err != nil {
+		return err
+	}
+	Ed := &models.RegisterEmailData{
+		Type:     models.EmailTypeResetPassword,
+		Email:    email,
+		UserName: username,
+		Token:    token,
+	}
+	zap.L().Debug("send reset password email", zap.Uint64("user_id", userID))
+	if err := rabbitmq.PublishEmailTask(Ed); err != nil {
+		return fmt.Errorf("failed to publish email task: %w", err)
+	}
+	return nil
+}
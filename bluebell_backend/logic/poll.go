@@ -0,0 +1,205 @@
+package logic
+
+import (
+	"bluebell_backend/dao/mysql"
+	"bluebell_backend/dao/redis"
+	"bluebell_backend/models"
+	"bluebell_backend/pkg/jwt"
+	"database/sql"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+/*
+帖子投票(Poll)
+	* 投票随帖子创建，保存在MySQL poll/poll_option表中；帖子发布时在redis中创建投票
+	* 投票过程只写redis：lua脚本原子地校验截止时间、选项，并保证每个用户只能投一次
+	* 到达截止时间后redis不再接受投票，后台任务将最终结果保存到MySQL，之后从MySQL读取结果
+	* 作者、社区版主和管理员可以提前截止投票
+*/
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 10
+	maxPollOptionLength = 64
+	pollCloseBatch      = 100 // 每次截止的投票数
+)
+
+// validatePoll 校验并规范化投票的选项和截止时间，publishAt为帖子的发布时间
+func validatePoll(p *models.ParamPoll, publishAt time.Time) error {
+	if len(p.Options) < minPollOptions || len(p.Options) > maxPollOptions {
+		return ErrorInvalidPoll
+	}
+	for i, option := range p.Options {
+		option = strings.TrimSpace(option)
+		if option == "" || utf8.RuneCountInString(option) > maxPollOptionLength {
+			return ErrorInvalidPoll
+		}
+		p.Options[i] = option
+	}
+	if p.CloseAt != nil && !p.CloseAt.After(publishAt) {
+		return ErrorInvalidPoll
+	}
+	return nil
+}
+
+// indexPoll 帖子发布时在redis中创建投票，帖子没有投票时不做处理
+func indexPoll(postID uint64) (err error) {
+	poll, err := mysql.GetPoll(postID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return
+	}
+	if poll.Closed {
+		return nil
+	}
+	options, err := mysql.GetPollOptions(postID)
+	if err != nil {
+		return
+	}
+	var closeAt time.Time
+	if poll.CloseAt != nil {
+		closeAt = *poll.CloseAt
+	}
+	return redis.CreatePoll(postID, len(options), poll.Multiple, closeAt)
+}
+
+// getPoll 查询帖子的投票及结果，帖子没有投票时返回nil
+func getPoll(postID uint64) (*models.ApiPoll, error) {
+	poll, err := mysql.GetPoll(postID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	options, err := mysql.GetPollOptions(postID)
+	if err != nil {
+		return nil, err
+	}
+	// 未截止的投票从redis中读取实时结果
+	if !poll.Closed {
+		votes, voters, err := redis.GetPollResult(postID)
+		if err != nil {
+			return nil, err
+		}
+		for _, option := range options {
+			option.Votes = votes[option.Index]
+		}
+		poll.Voters = voters
+	}
+	return &models.ApiPoll{Poll: poll, Options: options}, nil
+}
+
+// VotePoll 参与帖子的投票，返回最新结果
+func VotePoll(userID, postID uint64, p *models.ParamPollVote) (*models.ApiPoll, error) {
+	if err := checkPostUnlocked(postID); err != nil {
+		return nil, err
+	}
+	// 去掉重复的选项
+	seen := make(map[int]bool, len(p.Options))
+	options := make([]int, 0, len(p.Options))
+	for _, option := range p.Options {
+		if !seen[option] {
+			seen[option] = true
+			options = append(options, option)
+		}
+	}
+	switch err := redis.VoteForPoll(postID, userID, options); err {
+	case nil:
+	case redis.ErrorPollNotExist:
+		return nil, ErrorPollNotExist
+	case redis.ErrorPollClosed:
+		return nil, ErrorPollClosed
+	case redis.ErrorPollVoted:
+		return nil, ErrorPollVoted
+	case redis.ErrorInvalidPollOption:
+		return nil, ErrorInvalidPollOption
+	default:
+		return nil, err
+	}
+	poll, err := getPoll(postID)
+	if err != nil {
+		return nil, err
+	}
+	if poll != nil {
+		poll.Voted = options
+	}
+	return poll, nil
+}
+
+// ClosePoll 作者、社区版主或管理员提前截止投票
+func ClosePoll(mc *jwt.MyClaims, postID uint64) (err error) {
+	post, err := getPostForManage(mc, postID)
+	if err != nil {
+		return
+	}
+	poll, err := mysql.GetPoll(post.PostID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrorPollNotExist
+		}
+		return
+	}
+	if poll.Closed {
+		return ErrorPollClosed
+	}
+	_, err = closePoll(postID)
+	return
+}
+
+// closePoll 截止投票并将最终结果保存到MySQL，投票已被其他实例截止时返回false
+func closePoll(postID uint64) (bool, error) {
+	votes, voters, err := redis.ClosePoll(postID)
+	if err != nil {
+		return false, err
+	}
+	return mysql.ClosePoll(postID, votes, voters)
+}
+
+// closeDuePolls 截止已到截止时间的投票
+func closeDuePolls(interval time.Duration) (err error) {
+	token, err := randomToken()
+	if err != nil {
+		return
+	}
+	locked, err := redis.TryLock(redis.KeyPollCloserLock, token, interval)
+	if err != nil || !locked {
+		return
+	}
+	defer func() {
+		if err := redis.Unlock(redis.KeyPollCloserLock, token); err != nil {
+			zap.L().Warn("redis.Unlock failed", zap.Error(err))
+		}
+	}()
+
+	postIDs, err := mysql.GetDuePolls(time.Now(), pollCloseBatch)
+	if err != nil {
+		return
+	}
+	for _, postID := range postIDs {
+		if _, err := closePoll(postID); err != nil {
+			zap.L().Error("closePoll failed", zap.Uint64("post_id", postID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// StartPollCloser 启动截止投票的后台任务，interval为扫描间隔
+func StartPollCloser(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultPublishInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := closeDuePolls(interval); err != nil {
+			zap.L().Error("closeDuePolls failed", zap.Error(err))
+		}
+	}
+}
@@ -4,15 +4,22 @@ import (
 	"bluebell_backend/dao/mysql"
 	"bluebell_backend/dao/redis"
 	"bluebell_backend/models"
+	"bluebell_backend/pkg/jwt"
+	"bluebell_backend/pkg/markdown"
 	"bluebell_backend/pkg/snowflake"
+	"database/sql"
 	"fmt"
 	"strconv"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 // CreatePost 创建帖子
 func CreatePost(post *models.Post) (err error) {
+	if post.Tags, err = NormalizeTags(post.Tags); err != nil {
+		return
+	}
 	// 1.根据雪花算法生成post_id(帖子ID)
 	postID, err := snowflake.GetID()
 	if err != nil {
@@ -20,30 +27,71 @@ func CreatePost(post *models.Post) (err error) {
 		return
 	}
 	post.PostID = postID
+	if _, err = mysql.GetCommunityNameByID(fmt.Sprint(post.CommunityID)); err != nil {
+		zap.L().Error("mysql.GetCommunityNameByID failed", zap.Error(err))
+		return err
+	}
+	// 草稿和定时发布的帖子只保存到数据库，发布时才写入redis
+	switch {
+	case post.PublishAt != nil && post.PublishAt.After(time.Now()):
+		post.Status = models.PostStatusScheduled
+	case post.Draft:
+		post.Status = models.PostStatusDraft
+		post.PublishAt = nil
+	default:
+		post.Status = models.PostStatusPublished
+		now := time.Now()
+		post.PublishAt = &now
+	}
+	if post.Poll != nil {
+		publishAt := time.Now()
+		if post.Status == models.PostStatusScheduled {
+			publishAt = *post.PublishAt
+		}
+		if err = validatePoll(post.Poll, publishAt); err != nil {
+			return
+		}
+	}
 	// 2.插入数据库
 	if err := mysql.CreatePost(post); err != nil {
+		if err == mysql.ErrorInvalidAttachment {
+			return ErrorInvalidAttachment
+		}
 		zap.L().Error("mysql.CreatePost(&post) failed", zap.Error(err))
 		return err
 	}
-	community, err := mysql.GetCommunityNameByID(fmt.Sprint(post.CommunityID))
-	if err != nil {
-		zap.L().Error("mysql.GetCommunityNameByID failed", zap.Error(err))
-		return err
+	if post.Status != models.PostStatusPublished {
+		return nil
 	}
 
 	// 3.redis缓存帖子信息
-	if err := redis.CreatePost(
-		post.PostID,
-		post.AuthorId,
-		post.Title,
-		TruncateByWords(post.Content, 120),
-		community.CommunityID); err != nil {
+	if err := indexPost(post); err != nil {
 		zap.L().Error("redis.CreatePost failed", zap.Error(err))
 		return err
 	}
 	return
 }
 
+// indexPost 将发布的帖子写入redis，帖子从此出现在帖子列表和标签中
+func indexPost(post *models.Post) (err error) {
+	// 定时发布的帖子从数据库中查出时没有标签
+	if post.Tags == nil {
+		if post.Tags, err = mysql.GetPostTags(post.PostID); err != nil {
+			return
+		}
+	}
+	if err = redis.CreatePost(
+		post.PostID,
+		post.AuthorId,
+		post.Title,
+		summarize(post.Content),
+		post.CommunityID,
+		post.Tags); err != nil {
+		return
+	}
+	return indexPoll(post.PostID)
+}
+
 // GetPostById 根据Id查询帖子详情
 func GetPostById(postID int64) (data *models.ApiPostDetail, err error) {
 	// 1.查询帖子信息，根据post_id
@@ -72,6 +120,23 @@ func GetPostById(postID int64) (data *models.ApiPostDetail, err error) {
 	}
 	// 根据帖子id查询帖子的投票数
 	voteNum, err := redis.GetPostVoteNum(postID)
+	if post.Tags, err = mysql.GetPostTags(post.PostID); err != nil {
+		zap.L().Error("mysql.GetPostTags() failed",
+			zap.Uint64("post_id", post.PostID),
+			zap.Error(err))
+	}
+	attachments, err := getPostAttachments(post.PostID)
+	if err != nil {
+		zap.L().Error("getPostAttachments() failed",
+			zap.Uint64("post_id", post.PostID),
+			zap.Error(err))
+	}
+	poll, err := getPoll(post.PostID)
+	if err != nil {
+		zap.L().Error("getPoll() failed",
+			zap.Uint64("post_id", post.PostID),
+			zap.Error(err))
+	}
 
 	// 拼接帖子详情并返回
 	data = &models.ApiPostDetail{
@@ -79,10 +144,64 @@ func GetPostById(postID int64) (data *models.ApiPostDetail, err error) {
 		CommunityDetailRes: community,
 		AuthorName:         user.UserName,
 		VoteNum:            voteNum,
+		ContentHTML:        markdown.Render(post.Content),
+		Attachments:        attachments,
+		Poll:               poll,
 	}
+	fillPostCommentNum([]*models.ApiPostDetail{data})
 	return data, nil
 }
 
+// getPostDetailsByIDs 按ids顺序查询帖子详情，并填充作者、社区、标签及赞成票数量
+func getPostDetailsByIDs(ids []string) ([]*models.ApiPostDetail, error) {
+	list := make([]*models.ApiPostDetail, 0, len(ids))
+	if len(ids) == 0 {
+		return list, nil
+	}
+	// 1.查询ids中每篇帖子的赞成票数量
+	voteData, err := redis.GetPostVoteData(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2.根据ids去数据库查询帖子详细信息，并按传入的ids顺序返回结果
+	posts, err := mysql.GetPostListByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	fillPostTags(posts)
+
+	// 3.拼接数据：将帖子的作者及分区信息查询出来填充到帖子中
+	// 已删除的帖子不会从数据库中查出，投票数按post_id对应
+	votes := make(map[string]int64, len(ids))
+	for idx, id := range ids {
+		votes[id] = voteData[idx]
+	}
+	for _, post := range posts {
+		postDetail := &models.ApiPostDetail{
+			VoteNum: votes[strconv.FormatUint(post.PostID, 10)],
+			Post:    post,
+		}
+		if user, err := mysql.GetUserByID(post.AuthorId); err != nil {
+			zap.L().Error("mysql.GetUserByID() failed",
+				zap.Uint64("author_id", post.AuthorId),
+				zap.Error(err))
+		} else {
+			postDetail.AuthorName = user.UserName
+		}
+		if community, err := mysql.GetCommunityByID(post.CommunityID); err != nil {
+			zap.L().Error("mysql.GetCommunityByID() failed",
+				zap.Uint64("community_id", post.CommunityID),
+				zap.Error(err))
+		} else {
+			postDetail.CommunityDetailRes = community
+		}
+		list = append(list, postDetail)
+	}
+	fillPostCommentNum(list)
+	return list, nil
+}
+
 // GetPostList 获取帖子列表
 func GetPostList(page, size int64) ([]*models.ApiPostDetail, error) {
 	// 1.获取帖子列表
@@ -118,6 +237,7 @@ func GetPostList(page, size int64) ([]*models.ApiPostDetail, error) {
 		}
 		data = append(data, postDetail)
 	}
+	fillPostCommentNum(data)
 	return data, nil
 }
 
@@ -153,6 +273,7 @@ func GetPostList2(p *models.ParamPostList) (*models.ApiPostDetailRes, error) {
 	if err != nil {
 		return nil, err
 	}
+	fillPostTags(posts)
 	res.Page.Page = p.Page
 	res.Page.Size = p.Size
 	res.List = make([]*models.ApiPostDetail, 0, len(posts))
@@ -183,6 +304,7 @@ func GetPostList2(p *models.ParamPostList) (*models.ApiPostDetailRes, error) {
 		}
 		res.List = append(res.List, postDetail)
 	}
+	fillPostCommentNum(res.List)
 	return &res, nil
 }
 
@@ -218,6 +340,7 @@ func GetCommunityPostList(p *models.ParamPostList) (*models.ApiPostDetailRes, er
 	if err != nil {
 		return nil, err
 	}
+	fillPostTags(posts)
 	res.Page.Page = p.Page
 	res.Page.Size = p.Size
 	res.List = make([]*models.ApiPostDetail, 0, len(posts))
@@ -254,13 +377,17 @@ func GetCommunityPostList(p *models.ParamPostList) (*models.ApiPostDetailRes, er
 		}
 		res.List = append(res.List, postDetail)
 	}
+	fillPostCommentNum(res.List)
 	return &res, nil
 }
 
 // GetPostListNew 将两个查询帖子列表逻辑合二为一的函数
 func GetPostListNew(p *models.ParamPostList) (data *models.ApiPostDetailRes, err error) {
 	// 根据请求参数的不同,执行不同的业务逻辑
-	if p.CommunityID == 0 {
+	if len(p.Tags) > 0 {
+		// 按标签过滤(可同时指定社区)
+		data, err = GetTagPostList(p)
+	} else if p.CommunityID == 0 {
 		// 查所有帖子
 		data, err = GetPostList2(p)
 	} else {
@@ -292,6 +419,7 @@ func PostSearch(p *models.ParamPostList) (*models.ApiPostDetailRes, error) {
 	if len(posts) == 0 {
 		return &models.ApiPostDetailRes{}, nil
 	}
+	fillPostTags(posts)
 	// 2、查询出来的帖子id列表传入到redis接口获取帖子的投票数
 	ids := make([]string, 0, len(posts))
 	for _, post := range posts {
@@ -331,5 +459,143 @@ func PostSearch(p *models.ParamPostList) (*models.ApiPostDetailRes, error) {
 		}
 		res.List = append(res.List, postDetail)
 	}
+	fillPostCommentNum(res.List)
 	return &res, nil
 }
+
+// checkPostUnlocked 检查帖子是否存在且未被锁定，锁定的帖子不允许评论和投票
+func checkPostUnlocked(postID uint64) error {
+	locked, err := mysql.IsPostLocked(postID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrorPostNotExist
+		}
+		return err
+	}
+	if locked {
+		return ErrorPostLocked
+	}
+	return nil
+}
+
+// getPostForManage 查询帖子并校验当前用户是否可以管理：作者本人、帖子所属社区的版主或管理员
+// 草稿和定时发布的帖子只有作者可见
+func getPostForManage(mc *jwt.MyClaims, postID uint64) (post *models.Post, err error) {
+	post, err = mysql.GetPostByIDIncludeDraft(postID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrorPostNotExist
+		}
+		return
+	}
+	if post.AuthorId == mc.UserID {
+		return post, nil
+	}
+	if post.Status != models.PostStatusPublished {
+		return nil, ErrorPostNotExist
+	}
+	ok, err := canModerate(mc, post.CommunityID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrorNoPermission
+	}
+	return post, nil
+}
+
+// canModerate 判断当前用户是否可以管理社区：社区的版主或管理员
+func canModerate(mc *jwt.MyClaims, communityID uint64) (bool, error) {
+	switch mc.Role {
+	case models.RoleAdmin:
+		return true, nil
+	case models.RoleModerator:
+		return mysql.IsCommunityModerator(communityID, mc.UserID)
+	}
+	return false, nil
+}
+
+// UpdatePost 编辑帖子，同步更新redis中的帖子信息
+func UpdatePost(mc *jwt.MyClaims, postID uint64, p *models.ParamUpdatePost) (err error) {
+	post, err := getPostForManage(mc, postID)
+	if err != nil {
+		return
+	}
+	communityID := post.CommunityID
+	if p.CommunityID != 0 && p.CommunityID != communityID {
+		if _, err = mysql.GetCommunityByID(p.CommunityID); err != nil {
+			if err.Error() == mysql.ErrorInvalidID {
+				return ErrorCommunityNotExist
+			}
+			return
+		}
+		// 版主只能将帖子移动到自己同样可以管理的社区
+		if post.AuthorId != mc.UserID {
+			ok, err := canModerate(mc, p.CommunityID)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return ErrorNoPermission
+			}
+		}
+		communityID = p.CommunityID
+	}
+	if p.Tags != nil {
+		if err = updatePostTags(post, p.Tags); err != nil {
+			return
+		}
+	}
+	if err = linkAttachments(postID, mc.UserID, p.AttachmentIDs); err != nil {
+		return
+	}
+	return savePost(mc.UserID, post, p.Title, p.Content, communityID)
+}
+
+// updatePostTags 修改帖子标签，已发布的帖子同步redis中的标签集合
+func updatePostTags(post *models.Post, tags []string) (err error) {
+	if tags, err = NormalizeTags(tags); err != nil {
+		return
+	}
+	oldTags, err := mysql.ReplacePostTags(post.PostID, tags)
+	if err != nil {
+		return
+	}
+	if post.Status != models.PostStatusPublished {
+		return nil
+	}
+	return redis.UpdatePostTags(post.PostID, oldTags, tags)
+}
+
+// savePost 保存帖子的新版本，并同步redis中的帖子信息
+func savePost(editorID uint64, post *models.Post, title, content string, communityID uint64) (err error) {
+	if _, err = mysql.UpdatePost(post.PostID, title, content, communityID, editorID); err != nil {
+		return
+	}
+	if post.Status != models.PostStatusPublished { // 未发布的帖子不在redis中
+		return nil
+	}
+	return redis.UpdatePost(post.PostID, title, summarize(content), post.CommunityID, communityID)
+}
+
+// DeletePost 删除帖子：MySQL中软删除，并清除redis中的帖子信息
+func DeletePost(mc *jwt.MyClaims, postID uint64) (err error) {
+	post, err := getPostForManage(mc, postID)
+	if err != nil {
+		return
+	}
+	return removePost(postID, post.CommunityID)
+}
+
+// removePost 软删除帖子，并从redis的帖子列表、社区帖子集合中移除
+func removePost(postID, communityID uint64) (err error) {
+	if err = mysql.RemovePost(postID); err != nil {
+		return
+	}
+	// 帖子标签保留在数据库中，只从redis的标签集合中移除
+	tags, err := mysql.GetPostTags(postID)
+	if err != nil {
+		return
+	}
+	return redis.RemovePost(postID, communityID, tags)
+}
@@ -0,0 +1,59 @@
+package logic
+
+import (
+	"bluebell_backend/dao/mysql"
+	"bluebell_backend/dao/redis"
+	"bluebell_backend/models"
+
+	"go.uber.org/zap"
+)
+
+// GetUserProfile 查询用户主页：公开资料、发帖数、评论数以及karma
+func GetUserProfile(userID uint64) (data *models.ApiUserProfile, err error) {
+	profile, err := mysql.GetUserProfile(userID)
+	if err != nil {
+		return
+	}
+	postIDs, err := mysql.GetUserPostIDs(userID)
+	if err != nil {
+		return
+	}
+	commentCount, err := mysql.GetUserCommentCount(userID)
+	if err != nil {
+		return
+	}
+	karma, err := redis.GetUserKarma(userID, postIDs)
+	if err != nil {
+		return
+	}
+	data = &models.ApiUserProfile{
+		UserProfile:  profile,
+		PostCount:    int64(len(postIDs)),
+		CommentCount: commentCount,
+		Karma:        karma,
+	}
+	return
+}
+
+// GetMyProfile 查询当前用户的资料
+func GetMyProfile(userID uint64) (*models.ApiMyProfile, error) {
+	return mysql.GetMyProfile(userID)
+}
+
+// UpdateMyProfile 修改当前用户的资料，邮箱变更后需要重新验证
+func UpdateMyProfile(userID uint64, p *models.ParamUpdateProfile) (data *models.ApiMyProfile, err error) {
+	old, err := mysql.GetMyProfile(userID)
+	if err != nil {
+		return
+	}
+	if err = mysql.UpdateUserProfile(userID, p); err != nil {
+		return
+	}
+	if p.Email != "" && p.Email != old.Email {
+		// 验证邮件发送失败不影响资料修改，用户可以重新发送
+		if err := sendVerifyEmail(userID, old.UserName, p.Email); err != nil {
+			zap.L().Error("sendVerifyEmail failed", zap.Uint64("user_id", userID), zap.Error(err))
+		}
+	}
+	return mysql.GetMyProfile(userID)
+}
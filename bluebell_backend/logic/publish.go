@@ -0,0 +1,122 @@
+package logic
+
+import (
+	"bluebell_backend/dao/mysql"
+	"bluebell_backend/dao/redis"
+	"bluebell_backend/models"
+	"bluebell_backend/pkg/jwt"
+	"database/sql"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+/*
+草稿与定时发布
+	* 草稿和定时发布的帖子只保存在MySQL中，不出现在帖子列表和搜索中
+	* 发布时才写入redis(帖子信息、时间/分数ZSet、社区帖子Set)，帖子时间即为发布时间
+	* 后台任务定时扫描到期的帖子：redis锁保证同一时刻只有一个实例扫描，
+	  先写入redis再通过MySQL条件更新(status = 3 -> 1)发布，保证每个帖子只发布一次且发布后一定出现在列表中
+*/
+
+const (
+	defaultPublishInterval = 10 * time.Second
+	publishBatchSize       = 100 // 每次扫描最多发布的帖子数
+)
+
+// GetDraftList 分页查询当前用户的草稿和定时发布的帖子
+func GetDraftList(userID uint64, page, size int64) ([]*models.Post, error) {
+	return mysql.GetDraftList(userID, page, size)
+}
+
+// PublishDraft 作者发布草稿：publish_at为空或已过去时立即发布，否则改为定时发布
+func PublishDraft(mc *jwt.MyClaims, postID uint64, p *models.ParamPublishPost) (err error) {
+	post, err := mysql.GetPostByIDIncludeDraft(postID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrorPostNotExist
+		}
+		return
+	}
+	if post.AuthorId != mc.UserID {
+		return ErrorNoPermission
+	}
+	if post.Status == models.PostStatusPublished {
+		return ErrorPostPublished
+	}
+	if p.PublishAt != nil && p.PublishAt.After(time.Now()) {
+		return mysql.SchedulePost(postID, *p.PublishAt)
+	}
+	_, err = publishPost(post)
+	return
+}
+
+// publishPost 发布草稿或定时发布的帖子，帖子已被其他实例发布时返回false
+// 先写入redis再修改MySQL状态：redis中只写入不存在的数据，重复执行不会重置已发布帖子的数据，列表只返回MySQL中已发布的帖子，
+// 修改状态前中断时帖子仍为定时发布状态，下次扫描时重试
+func publishPost(post *models.Post) (ok bool, err error) {
+	if err = indexPost(post); err != nil {
+		return
+	}
+	ok, err = mysql.PublishPost(post.PostID, post.Status)
+	if err != nil || ok {
+		return
+	}
+	// 帖子已被其他实例发布时保留redis中的数据，已被删除或修改了状态时撤销写入
+	current, err := mysql.GetPostByIDIncludeDraft(post.PostID)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+	if err == nil && current.Status == models.PostStatusPublished {
+		return false, nil
+	}
+	return false, redis.RemovePost(post.PostID, post.CommunityID, post.Tags)
+}
+
+// publishDuePosts 发布已到发布时间的帖子
+func publishDuePosts(interval time.Duration) (err error) {
+	token, err := randomToken()
+	if err != nil {
+		return
+	}
+	// 多个实例同时运行时只有获得锁的实例扫描
+	locked, err := redis.TryLock(redis.KeyPostPublisherLock, token, interval)
+	if err != nil || !locked {
+		return
+	}
+	defer func() {
+		if err := redis.Unlock(redis.KeyPostPublisherLock, token); err != nil {
+			zap.L().Warn("redis.Unlock failed", zap.Error(err))
+		}
+	}()
+
+	posts, err := mysql.GetDuePosts(time.Now(), publishBatchSize)
+	if err != nil {
+		return
+	}
+	for _, post := range posts {
+		ok, err := publishPost(post)
+		if err != nil {
+			zap.L().Error("publish scheduled post failed", zap.Uint64("post_id", post.PostID), zap.Error(err))
+			continue
+		}
+		if ok {
+			zap.L().Info("scheduled post published", zap.Uint64("post_id", post.PostID))
+		}
+	}
+	return nil
+}
+
+// StartPostPublisher 启动定时发布帖子的后台任务，interval为扫描间隔
+func StartPostPublisher(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultPublishInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := publishDuePosts(interval); err != nil {
+			zap.L().Error("publishDuePosts failed", zap.Error(err))
+		}
+	}
+}
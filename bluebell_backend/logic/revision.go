@@ -0,0 +1,88 @@
+package logic
+
+import (
+	"bluebell_backend/dao/mysql"
+	"bluebell_backend/models"
+	"bluebell_backend/pkg/jwt"
+	"database/sql"
+)
+
+// getPost 查询未删除的帖子
+func getPost(postID uint64) (*models.Post, error) {
+	post, err := mysql.GetPostByID(int64(postID))
+	if err != nil {
+		if err.Error() == mysql.ErrorInvalidID {
+			return nil, ErrorPostNotExist
+		}
+		return nil, err
+	}
+	return post, nil
+}
+
+// GetPostRevisions 查询帖子的版本列表
+func GetPostRevisions(postID uint64) ([]*models.PostRevision, error) {
+	if _, err := getPost(postID); err != nil {
+		return nil, err
+	}
+	return mysql.GetPostRevisions(postID)
+}
+
+// getRevision 查询帖子的指定版本
+func getRevision(postID uint64, revision int64) (*models.PostRevision, error) {
+	r, err := mysql.GetPostRevision(postID, revision)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrorRevisionNotExist
+		}
+		return nil, err
+	}
+	return r, nil
+}
+
+// DiffPostRevisions 比较帖子的两个版本，标题按词比较，内容按行或按词比较
+func DiffPostRevisions(postID uint64, p *models.ParamRevisionDiff) (data *models.ApiRevisionDiff, err error) {
+	if _, err = getPost(postID); err != nil {
+		return
+	}
+	from, err := getRevision(postID, p.From)
+	if err != nil {
+		return
+	}
+	to, err := getRevision(postID, p.To)
+	if err != nil {
+		return
+	}
+	data = &models.ApiRevisionDiff{
+		From:  p.From,
+		To:    p.To,
+		Mode:  "line",
+		Title: DiffWords(from.Title, to.Title),
+	}
+	if p.Mode == "word" {
+		data.Mode = p.Mode
+		data.Content = DiffWords(from.Content, to.Content)
+	} else {
+		data.Content = DiffLines(from.Content, to.Content)
+	}
+	return
+}
+
+// RestorePostRevision 版主将帖子恢复到指定版本，恢复操作本身保存为一个新版本
+func RestorePostRevision(mc *jwt.MyClaims, postID uint64, revision int64) (err error) {
+	post, err := getPost(postID)
+	if err != nil {
+		return
+	}
+	ok, err := canModerate(mc, post.CommunityID)
+	if err != nil {
+		return
+	}
+	if !ok {
+		return ErrorNoPermission
+	}
+	r, err := getRevision(postID, revision)
+	if err != nil {
+		return
+	}
+	return savePost(mc.UserID, post, r.Title, r.Content, post.CommunityID)
+}
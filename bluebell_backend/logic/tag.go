@@ -0,0 +1,131 @@
+package logic
+
+import (
+	"bluebell_backend/dao/mysql"
+	"bluebell_backend/dao/redis"
+	"bluebell_backend/models"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+/*
+帖子标签
+	* 标签规范化：去掉首尾空白和开头的#，转为小写，中间的空白替换为-，只允许字母、数字、-和_
+	* 每篇帖子最多maxPostTags个标签，重复的标签只保留一个
+	* MySQL post_tag表保存帖子的标签；已发布的帖子同时加入redis中标签的帖子Set，热门标签ZSet记录每个标签的帖子数量
+*/
+
+const (
+	maxPostTags  = 5  // 每篇帖子的标签数量上限
+	maxTagLength = 20 // 标签的最大字符数
+)
+
+// NormalizeTags 规范化帖子标签并去重
+func NormalizeTags(tags []string) ([]string, error) {
+	res := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag, ok := normalizeTag(tag)
+		if !ok {
+			return nil, ErrorInvalidTag
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		res = append(res, tag)
+	}
+	if len(res) > maxPostTags {
+		return nil, ErrorTooManyTags
+	}
+	return res, nil
+}
+
+// normalizeTag 规范化单个标签，标签为空、过长或包含不允许的字符时返回false
+func normalizeTag(tag string) (string, bool) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+	tag = strings.ToLower(strings.Join(strings.Fields(tag), "-"))
+	if tag == "" || utf8.RuneCountInString(tag) > maxTagLength {
+		return "", false
+	}
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return "", false
+		}
+	}
+	return tag, true
+}
+
+// fillPostTags 批量查询并填充帖子的标签
+func fillPostTags(posts []*models.Post) {
+	ids := make([]uint64, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.PostID)
+	}
+	tags, err := mysql.GetPostTagsByIDs(ids)
+	if err != nil {
+		zap.L().Error("mysql.GetPostTagsByIDs failed", zap.Error(err))
+		return
+	}
+	for _, post := range posts {
+		post.Tags = tags[post.PostID]
+	}
+}
+
+// GetPopularTags 查询帖子数量最多的标签
+func GetPopularTags(size int64) ([]*models.ApiTag, error) {
+	return redis.GetPopularTags(size)
+}
+
+// GetTagDetail 标签页：查询标签的帖子数量及该标签下的帖子列表
+func GetTagDetail(name string, p *models.ParamPostList) (*models.ApiTagDetail, error) {
+	tag, ok := normalizeTag(name)
+	if !ok {
+		return nil, ErrorInvalidTag
+	}
+	count, err := redis.GetTagPostCount(tag)
+	if err != nil {
+		return nil, err
+	}
+	p.Tags = []string{tag}
+	posts, err := GetTagPostList(p)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ApiTagDetail{
+		ApiTag: &models.ApiTag{Name: tag, PostCount: count},
+		Posts:  posts,
+	}, nil
+}
+
+// GetTagPostList 按发布时间/分数排序分页获取同时包含指定标签的帖子列表，可以同时按社区过滤
+func GetTagPostList(p *models.ParamPostList) (*models.ApiPostDetailRes, error) {
+	tags, err := NormalizeTags(p.Tags)
+	if err != nil {
+		return nil, err
+	}
+	p.Tags = tags
+
+	var res models.ApiPostDetailRes
+	res.Page.Page = p.Page
+	res.Page.Size = p.Size
+	res.List = make([]*models.ApiPostDetail, 0)
+	// 1.根据排序规则去redis查询帖子ids及总数
+	ids, total, err := redis.GetTagPostIDsInOrder(p)
+	if err != nil {
+		return nil, err
+	}
+	res.Page.Total = total
+	if len(ids) == 0 {
+		return &res, nil
+	}
+
+	// 2.查询帖子详情、作者、社区及赞成票数量
+	if res.List, err = getPostDetailsByIDs(ids); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
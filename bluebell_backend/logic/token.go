@@ -0,0 +1,163 @@
+package logic
+
+import (
+	"bluebell_backend/dao/mysql"
+	"bluebell_backend/dao/redis"
+	"bluebell_backend/pkg/jwt"
+	"bluebell_backend/settings"
+	"database/sql"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+/*
+Token管理
+	* 限制账号同时登录的设备数：Access Token登记到Redis中，过期时间与Token相同
+	* 超出设备数上限被挤下线的设备吊销整个Token家族，不能再用refresh_token重新登录
+	* 一次登录签发的Token属于同一家族，refresh_token只能使用一次，使用后轮换；重放时吊销整个家族
+	* 注销：将Token加入Redis黑名单，黑名单记录在Token过期时自动删除
+*/
+
+// issueTokens 登录时生成新家族的Access Token和Refresh Token并登记到Redis中
+func issueTokens(userID uint64, username, role string) (aToken, rToken string, err error) {
+	family, err := jwt.NewFamilyID()
+	if err != nil {
+		return
+	}
+	aToken, rToken, err = jwt.GenToken(userID, username, role, family)
+	if err != nil {
+		return
+	}
+	mc, rc, err := parseTokenPair(aToken, rToken)
+	if err != nil {
+		return "", "", err
+	}
+	accessExp, refreshExp := time.Unix(mc.ExpiresAt, 0), time.Unix(rc.ExpiresAt, 0)
+	evicted, err := redis.SaveSession(userID, family, mc.Id, accessExp, maxDevices())
+	if err != nil {
+		return "", "", err
+	}
+	if err = revokeEvicted(userID, evicted); err != nil {
+		return "", "", err
+	}
+	if err = redis.SaveTokenFamily(userID, family, rc.Id, refreshExp, mc.Id, accessExp); err != nil {
+		return "", "", err
+	}
+	return
+}
+
+// parseTokenPair 解析刚签发的Token，取出jti和过期时间
+func parseTokenPair(aToken, rToken string) (*jwt.MyClaims, *jwt.RefreshClaims, error) {
+	mc, err := jwt.ParseToken(aToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc, err := jwt.ParseRefreshToken(rToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	return mc, rc, nil
+}
+
+// RefreshToken 使用refresh_token换取新的Token，旧的refresh_token随即失效
+func RefreshToken(rToken string) (newAToken, newRToken string, err error) {
+	// 1.校验refresh_token，用户ID和家族ID均来自refresh_token本身
+	rc, err := jwt.ParseRefreshToken(rToken)
+	if err != nil {
+		zap.L().Debug("jwt.ParseRefreshToken failed", zap.Error(err))
+		return "", "", ErrorInvalidToken
+	}
+	revoked, err := redis.IsTokenRevoked(rc.Id)
+	if err != nil {
+		return
+	}
+	if revoked {
+		return "", "", ErrorTokenRevoked
+	}
+	// 2.用户可能已被删除，同时获取最新的用户名和角色
+	user, err := mysql.GetUserByID(rc.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", "", ErrorInvalidToken
+		}
+		return
+	}
+	// 3.签发同一家族的新Token
+	newAToken, newRToken, err = jwt.GenToken(rc.UserID, user.UserName, user.Role, rc.Family)
+	if err != nil {
+		return
+	}
+	mc, newRc, err := parseTokenPair(newAToken, newRToken)
+	if err != nil {
+		return "", "", err
+	}
+	accessExp, refreshExp := time.Unix(mc.ExpiresAt, 0), time.Unix(newRc.ExpiresAt, 0)
+	// 4.原子地轮换家族的refresh_token，已被使用过的refresh_token再次出现说明Token泄露，吊销整个家族
+	oldAccessID, err := redis.RotateTokenFamily(rc.UserID, rc.Family, rc.Id, newRc.Id, refreshExp, mc.Id, accessExp)
+	switch err {
+	case nil:
+	case redis.ErrorRefreshTokenReused:
+		zap.L().Warn("refresh token reused, revoke token family",
+			zap.Uint64("user_id", rc.UserID), zap.String("family", rc.Family))
+		if err := redis.RevokeTokenFamily(rc.Family); err != nil {
+			zap.L().Error("redis.RevokeTokenFamily failed", zap.String("family", rc.Family), zap.Error(err))
+		}
+		return "", "", ErrorRefreshTokenReused
+	case redis.ErrorTokenFamilyNotFound:
+		return "", "", ErrorTokenRevoked
+	default:
+		return "", "", err
+	}
+	// 5.用新的Access Token替换该设备原来登记的Token
+	evicted, err := redis.RefreshSession(rc.UserID, rc.Family, oldAccessID, mc.Id, accessExp, maxDevices())
+	if err != nil {
+		return "", "", err
+	}
+	if err = revokeEvicted(rc.UserID, evicted); err != nil {
+		return "", "", err
+	}
+	return
+}
+
+// revokeEvicted 吊销超出设备数上限被挤下线的Token家族，使其refresh_token同样失效
+func revokeEvicted(userID uint64, families []string) error {
+	if len(families) == 0 {
+		return nil
+	}
+	zap.L().Info("device limit exceeded, revoke token families",
+		zap.Uint64("user_id", userID), zap.Strings("families", families))
+	return redis.RevokeTokenFamily(families...)
+}
+
+// Logout 注销当前设备：吊销当前Access Token所属的整个Token家族
+func Logout(mc *jwt.MyClaims) (err error) {
+	if err = redis.RemoveSession(mc.UserID, mc.Id); err != nil {
+		return
+	}
+	if err = redis.RevokeToken(mc.Id, time.Unix(mc.ExpiresAt, 0)); err != nil {
+		return
+	}
+	return redis.RevokeTokenFamily(mc.Family)
+}
+
+// LogoutAll 注销用户的所有设备
+func LogoutAll(userID uint64) error {
+	return redis.RevokeUserTokens(userID)
+}
+
+// ForceLogout 管理员强制注销指定用户的所有设备
+func ForceLogout(userID uint64) (err error) {
+	if _, err = mysql.GetUserByID(userID); err != nil {
+		return
+	}
+	return redis.RevokeUserTokens(userID)
+}
+
+// maxDevices 同一账号同时登录的设备数上限，默认只允许一个设备
+func maxDevices() int {
+	if settings.Conf.AuthConfig == nil || settings.Conf.MaxDevices < 1 {
+		return 1
+	}
+	return settings.Conf.MaxDevices
+}
@@ -1,12 +1,18 @@
 package logic
 
 import (
+	"bluebell_backend/pkg/markdown"
 	"unicode"
 	"unicode/utf8"
 )
 
 // 向redis中缓存帖子内容时截断字符串，优化查询和减少内存占用
 
+// summarize 根据帖子的Markdown内容生成摘要：先渲染为纯文本，避免摘要中出现Markdown语法
+func summarize(content string) string {
+	return TruncateByWords(markdown.PlainText(content), 120)
+}
+
 // TruncateByWords 根据最大单词数截断字符串，并添加省略号
 func TruncateByWords(s string, maxWords int) string {
 	processedWords := 0  // 记录已处理的单词数
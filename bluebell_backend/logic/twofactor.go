@@ -0,0 +1,175 @@
+package logic
+
+import (
+	"bluebell_backend/dao/mysql"
+	"bluebell_backend/dao/redis"
+	"bluebell_backend/models"
+	"bluebell_backend/pkg/totp"
+	"bluebell_backend/settings"
+	"time"
+)
+
+/*
+两步验证(TOTP)
+	* 开启：生成密钥和恢复码(状态为待确认) -> 用户在验证器应用中扫码并提交验证码确认后生效
+	* 登录：密码正确后返回临时凭证，提交验证码或恢复码后才签发Token
+	* 每个验证码只能使用一次，每个恢复码只能使用一次
+*/
+
+const (
+	TwoFactorTokenExpire = 5 * time.Minute // 登录第二步临时凭证的有效期
+	TwoFactorMaxAttempts = 5               // 每个临时凭证最多尝试的次数
+	recoveryCodeCount    = 10              // 恢复码数量
+)
+
+// totpClock 验证码使用的时钟，测试时可以替换为totp.FakeClock
+var totpClock totp.Clock = totp.SystemClock
+
+// newTOTP 根据配置创建TOTP
+func newTOTP() *totp.TOTP {
+	issuer := "Bluebell"
+	if settings.Conf.AuthConfig != nil && settings.Conf.TOTPIssuer != "" {
+		issuer = settings.Conf.TOTPIssuer
+	}
+	return totp.New(issuer, totpClock)
+}
+
+// EnrollTOTP 生成TOTP密钥和恢复码，需要提交验证码确认后才开启两步验证
+func EnrollTOTP(userID uint64) (data *models.ApiTOTPEnroll, err error) {
+	t, err := mysql.GetUserTOTP(userID)
+	if err != nil {
+		return
+	}
+	if t.Enabled {
+		return nil, ErrorTwoFactorEnabled
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return
+	}
+	codes, hashes, err := totp.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return
+	}
+	if err = mysql.SaveTOTPSecret(userID, secret, hashes); err != nil {
+		return
+	}
+	return &models.ApiTOTPEnroll{
+		Secret:        secret,
+		URI:           newTOTP().URI(t.UserName, secret),
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// EnableTOTP 提交验证器应用中的验证码，确认开启两步验证
+func EnableTOTP(userID uint64, code string) (err error) {
+	t, err := mysql.GetUserTOTP(userID)
+	if err != nil {
+		return
+	}
+	if t.Enabled {
+		return ErrorTwoFactorEnabled
+	}
+	if t.Secret == "" {
+		return ErrorTwoFactorNotEnabled
+	}
+	counter, ok, err := newTOTP().Validate(t.Secret, code)
+	if err != nil {
+		return
+	}
+	if !ok {
+		return ErrorInvalidTwoFactorCode
+	}
+	return mysql.EnableTOTP(userID, counter)
+}
+
+// DisableTOTP 校验密码后关闭两步验证
+func DisableTOTP(userID uint64, p *models.ParamDisable2FA) (err error) {
+	if err = mysql.CheckUserPassword(userID, p.Password); err != nil {
+		if err.Error() == mysql.ErrorPasswordWrong {
+			return ErrorPasswordWrong
+		}
+		return
+	}
+	t, err := mysql.GetUserTOTP(userID)
+	if err != nil {
+		return
+	}
+	if !t.Enabled && t.Secret == "" {
+		return ErrorTwoFactorNotEnabled
+	}
+	return mysql.DisableTOTP(userID)
+}
+
+// beginTwoFactorLogin 密码校验通过后生成登录第二步的临时凭证
+func beginTwoFactorLogin(userID uint64) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	if err := redis.SaveTwoFactorChallenge(token, userID, TwoFactorTokenExpire); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// LoginTwoFactor 登录第二步：校验验证码或恢复码，通过后签发Token
+func LoginTwoFactor(p *models.ParamLogin2FA) (user *models.User, err error) {
+	userID, attempts, err := redis.CheckTwoFactorChallenge(p.Token)
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrorInvalidTwoFactorToken
+		}
+		return
+	}
+	// 尝试次数过多时作废临时凭证，需要重新输入密码
+	if attempts > TwoFactorMaxAttempts {
+		if err := redis.DeleteTwoFactorChallenge(p.Token); err != nil {
+			return nil, err
+		}
+		return nil, ErrorInvalidTwoFactorToken
+	}
+	t, err := mysql.GetUserTOTP(userID)
+	if err != nil {
+		return
+	}
+	ok, err := verifySecondFactor(t, p.Code)
+	if err != nil {
+		return
+	}
+	if !ok {
+		return nil, ErrorInvalidTwoFactorCode
+	}
+	if err = redis.DeleteTwoFactorChallenge(p.Token); err != nil {
+		return
+	}
+
+	user, err = mysql.GetUserByID(userID)
+	if err != nil {
+		return
+	}
+	user.AccessToken, user.RefreshToken, err = issueTokens(user.UserID, user.UserName, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// verifySecondFactor 校验验证码或恢复码，验证码和恢复码都只能使用一次
+func verifySecondFactor(t *models.UserTOTP, code string) (bool, error) {
+	if !t.Enabled || t.Secret == "" {
+		return false, nil
+	}
+	return newTOTP().Verify(totpUsageStore{}, t.UserID, t.Secret, code)
+}
+
+// totpUsageStore 使用MySQL记录已使用的验证码时间步和恢复码
+type totpUsageStore struct{}
+
+func (totpUsageStore) UseCounter(userID, counter uint64) (bool, error) {
+	return mysql.UseTOTPCounter(userID, counter)
+}
+
+func (totpUsageStore) UseRecoveryCode(userID uint64, codeHash string) (bool, error) {
+	return mysql.UseRecoveryCode(userID, codeHash)
+}
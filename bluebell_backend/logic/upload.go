@@ -0,0 +1,270 @@
+package logic
+
+import (
+	"bluebell_backend/dao/mysql"
+	"bluebell_backend/dao/redis"
+	"bluebell_backend/models"
+	"bluebell_backend/pkg/snowflake"
+	"bluebell_backend/pkg/storage"
+	"bluebell_backend/pkg/thumbnail"
+	"bluebell_backend/settings"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+/*
+图片和附件上传
+	* 文件类型按文件内容识别，不信任客户端提供的Content-Type和扩展名
+	* 图片生成缩略图并记录宽高，保存的key由附件id生成，不使用用户提供的文件名
+	* 上传的附件先保存为未关联状态，创建或编辑帖子时关联到帖子
+	* 超过orphan_ttl仍未关联帖子的附件由后台任务清理，redis锁保证同一时刻只有一个实例清理
+*/
+
+const (
+	defaultMaxUploadSize = 10 << 20 // 默认单个文件大小上限10MB
+	defaultThumbnailSize = 320
+	defaultOrphanTTL     = 24 * time.Hour
+	defaultGCInterval    = time.Hour
+	attachmentGCBatch    = 100 // 每次清理的附件数
+	maxFileNameLen       = 255 // 保存的文件名最大字符数，与attachment.file_name一致
+
+	// MaxFileNameSize 上传时文件名的最大字节数，超过时直接拒绝
+	MaxFileNameSize = 1024
+)
+
+// defaultAllowedTypes 未配置allowed_types时允许上传的文件类型
+var defaultAllowedTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// fileExtensions 文件类型对应的扩展名
+var fileExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+	"application/zip": ".zip",
+}
+
+// MaxUploadSize 单个文件的大小上限(字节)
+func MaxUploadSize() int64 {
+	if cfg := settings.Conf.StorageConfig; cfg != nil && cfg.MaxSize > 0 {
+		return cfg.MaxSize << 20
+	}
+	return defaultMaxUploadSize
+}
+
+// allowedType 判断文件类型是否允许上传
+func allowedType(contentType string) bool {
+	allowed := defaultAllowedTypes
+	if cfg := settings.Conf.StorageConfig; cfg != nil && len(cfg.AllowedTypes) > 0 {
+		allowed = cfg.AllowedTypes
+	}
+	for _, t := range allowed {
+		if t == contentType {
+			return fileExtensions[t] != ""
+		}
+	}
+	return false
+}
+
+// Upload 保存上传的文件，图片同时生成缩略图
+func Upload(userID uint64, fileName string, r io.Reader) (a *models.Attachment, err error) {
+	// 1.读取文件并检查大小，多读一个字节用于判断是否超过上限
+	limit := MaxUploadSize()
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrorFileTooLarge
+	}
+	if len(data) == 0 {
+		return nil, ErrorFileType
+	}
+	// 2.根据文件内容识别类型
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil || !allowedType(contentType) {
+		return nil, ErrorFileType
+	}
+
+	id, err := snowflake.GetID()
+	if err != nil {
+		return
+	}
+	a = &models.Attachment{
+		AttachmentID: id,
+		UserID:       userID,
+		FileName:     cleanFileName(fileName),
+		ContentType:  contentType,
+		Size:         int64(len(data)),
+	}
+	prefix := fmt.Sprintf("attachments/%s/%d", time.Now().Format("2006/01"), id)
+	a.StorageKey = prefix + fileExtensions[contentType]
+
+	// 3.图片生成缩略图，无法解码的图片不允许上传
+	var thumb *thumbnail.Thumbnail
+	if strings.HasPrefix(contentType, "image/") {
+		if thumb, err = thumbnail.Generate(data, thumbnailSize()); err != nil {
+			zap.L().Debug("thumbnail.Generate failed", zap.Error(err))
+			if err == thumbnail.ErrImageTooLarge {
+				return nil, ErrorFileTooLarge
+			}
+			return nil, ErrorFileType
+		}
+		a.Width, a.Height = thumb.Width, thumb.Height
+		a.ThumbKey = prefix + "_thumb" + fileExtensions[thumb.ContentType]
+	}
+
+	// 4.保存文件和缩略图，失败时删除已保存的文件
+	if err = storage.Put(a.StorageKey, bytes.NewReader(data), a.Size, contentType); err != nil {
+		return nil, err
+	}
+	if thumb != nil {
+		if err = storage.Put(a.ThumbKey, thumb.Reader(), int64(len(thumb.Data)), thumb.ContentType); err != nil {
+			deleteAttachmentFiles(a)
+			return nil, err
+		}
+	}
+	// 5.保存附件记录
+	if err = mysql.CreateAttachment(a); err != nil {
+		deleteAttachmentFiles(a)
+		return nil, err
+	}
+	a.CreateTime = time.Now()
+	fillAttachmentURLs(a)
+	return a, nil
+}
+
+// cleanFileName 只保留文件名本身，并限制长度
+func cleanFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	if r := []rune(name); len(r) > maxFileNameLen {
+		name = string(r[:maxFileNameLen])
+	}
+	return name
+}
+
+func thumbnailSize() int {
+	if cfg := settings.Conf.StorageConfig; cfg != nil && cfg.ThumbnailSize > 0 {
+		return cfg.ThumbnailSize
+	}
+	return defaultThumbnailSize
+}
+
+// fillAttachmentURLs 根据当前存储填充附件的访问地址
+func fillAttachmentURLs(attachments ...*models.Attachment) {
+	for _, a := range attachments {
+		a.URL = storage.URL(a.StorageKey)
+		if a.ThumbKey != "" {
+			a.ThumbURL = storage.URL(a.ThumbKey)
+		}
+	}
+}
+
+// getPostAttachments 查询帖子的附件及访问地址
+func getPostAttachments(postID uint64) ([]*models.Attachment, error) {
+	attachments, err := mysql.GetPostAttachments(postID)
+	if err != nil {
+		return nil, err
+	}
+	fillAttachmentURLs(attachments...)
+	return attachments, nil
+}
+
+// linkAttachments 将用户上传的附件关联到帖子
+func linkAttachments(postID, userID uint64, ids []string) (err error) {
+	if len(ids) == 0 {
+		return
+	}
+	attachmentIDs := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		attachmentID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return ErrorInvalidAttachment
+		}
+		attachmentIDs = append(attachmentIDs, attachmentID)
+	}
+	err = mysql.LinkAttachments(postID, userID, attachmentIDs)
+	if err == mysql.ErrorInvalidAttachment {
+		return ErrorInvalidAttachment
+	}
+	return
+}
+
+// deleteAttachmentFiles 删除附件及缩略图文件
+func deleteAttachmentFiles(a *models.Attachment) {
+	for _, key := range []string{a.StorageKey, a.ThumbKey} {
+		if key == "" {
+			continue
+		}
+		if err := storage.Delete(key); err != nil {
+			zap.L().Error("storage.Delete failed", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+// collectOrphanAttachments 清理超过ttl仍未关联帖子的附件
+func collectOrphanAttachments(interval, ttl time.Duration) (err error) {
+	token, err := randomToken()
+	if err != nil {
+		return
+	}
+	locked, err := redis.TryLock(redis.KeyAttachmentGCLock, token, interval)
+	if err != nil || !locked {
+		return
+	}
+	defer func() {
+		if err := redis.Unlock(redis.KeyAttachmentGCLock, token); err != nil {
+			zap.L().Warn("redis.Unlock failed", zap.Error(err))
+		}
+	}()
+
+	attachments, err := mysql.GetOrphanAttachments(time.Now().Add(-ttl), attachmentGCBatch)
+	if err != nil {
+		return
+	}
+	for _, a := range attachments {
+		// 先删除记录，记录删除成功后附件不会再被关联，此时再删除文件
+		ok, err := mysql.DeleteOrphanAttachment(a.AttachmentID)
+		if err != nil {
+			zap.L().Error("mysql.DeleteOrphanAttachment failed", zap.Uint64("attachment_id", a.AttachmentID), zap.Error(err))
+			continue
+		}
+		if ok {
+			deleteAttachmentFiles(a)
+		}
+	}
+	return nil
+}
+
+// StartAttachmentGC 启动清理未关联附件的后台任务
+func StartAttachmentGC() {
+	interval, ttl := defaultGCInterval, defaultOrphanTTL
+	if cfg := settings.Conf.StorageConfig; cfg != nil {
+		if cfg.GCInterval > 0 {
+			interval = time.Duration(cfg.GCInterval) * time.Minute
+		}
+		if cfg.OrphanTTL > 0 {
+			ttl = time.Duration(cfg.OrphanTTL) * time.Hour
+		}
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := collectOrphanAttachments(interval, ttl); err != nil {
+			zap.L().Error("collectOrphanAttachments failed", zap.Error(err))
+		}
+	}
+}
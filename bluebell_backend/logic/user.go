@@ -3,15 +3,14 @@ package logic
 import (
 	"bluebell_backend/dao/mysql"
 	"bluebell_backend/models"
-	"bluebell_backend/pkg/jwt"
-	"bluebell_backend/pkg/rabbitmq"
 	"bluebell_backend/pkg/snowflake"
 	"fmt"
+
 	"go.uber.org/zap"
 )
 
 // 注册业务逻辑代码
-func SignUp(p *models.RegisterForm) (error error) {
+func SignUp(p *models.RegisterForm) (userID uint64, err error) {
 	//// 1.判断用户是否注册
 	//err := mysql.CheckUserExist(p.UserName)
 	//if err != nil {
@@ -21,7 +20,7 @@ func SignUp(p *models.RegisterForm) (error error) {
 	// 1.雪花算法生成UID
 	userId, err := snowflake.GetID()
 	if err != nil {
-		return mysql.ErrorGenIDFailed
+		return 0, mysql.ErrorGenIDFailed
 	}
 
 	// 2.写入数据库
@@ -32,7 +31,7 @@ func SignUp(p *models.RegisterForm) (error error) {
 		Email:    p.Email,
 		Gender:   p.Gender,
 	}
-	return mysql.InsertUser(u)
+	return userId, mysql.InsertUser(u)
 }
 
 // 登录业务逻辑代码
@@ -46,16 +45,21 @@ func Login(p *models.LoginForm) (user *models.User, error error) {
 		return nil, err
 	}
 
-	// 2.生成JWT：AccessToken和RefreshToken
-	accessToken, refreshToken, err := jwt.GenToken(user.UserID, user.UserName)
+	// 开启两步验证时只返回临时凭证，校验验证码后再签发Token
+	if user.TOTPEnabled {
+		token, err := beginTwoFactorLogin(user.UserID)
+		if err != nil {
+			return nil, err
+		}
+		user.TwoFactorToken = token
+		return user, nil
+	}
+
+	// 2.生成JWT：AccessToken和RefreshToken，并登记到Redis中
+	accessToken, refreshToken, err := issueTokens(user.UserID, user.UserName, user.Role)
 	if err != nil {
-		return
+		return nil, err
 	}
-	/*
-		限制账号在同一时间只能在一个设备上登录
-		* 将 {userID : Access Token} 缓存至 Redis中
-		* 设置过期时间与 Access Token 过期时间相同，避免Token过期但Redis中仍存在旧记录
-	*/
 	user.AccessToken = accessToken
 	user.RefreshToken = refreshToken
 	return
@@ -69,36 +73,20 @@ func SignUpNew(p *models.RegisterForm) error {
 		return err
 	}
 
-	var errs []error
-	// 若用户提供电子邮件，异步发送邮件到用户邮箱
-	if p.Email != "" {
-		Ed := &models.RegisterEmailData{
-			Email:    p.Email,
-			UserName: p.UserName,
-			Password: p.Password,
-		}
-		zap.L().Debug("emaildetail", zap.String("Username", Ed.UserName),
-			zap.String("Email", Ed.Email))
-		// 使用生产者发布邮件任务到队列
-		err := rabbitmq.PublishEmailTask(Ed)
-		if err != nil {
-			errs = append(errs, fmt.Errorf("failed to publish email task: %w;", err))
-		}
-		//// 不使用消息队列
-		//err := email.SendEmail(Ed)
-		//if err != nil {
-		//	errs = append(errs, err)
-		//}
-	}
-
 	// 用户注册逻辑 保存到mysql
-	if err := SignUp(p); err != nil {
-		errs = append(errs, fmt.Errorf("signup error: %w", err))
+	userID, err := SignUp(p)
+	if err != nil {
+		return fmt.Errorf("signup error: %w", err)
 	}
 	zap.L().Debug("signup success", zap.String("email", p.Email),
 		zap.String("username", p.UserName))
-	if len(errs) > 0 {
-		return fmt.Errorf("multiple errors occurred: %v", errs)
+
+	// 若用户提供电子邮件，异步发送验证邮件到用户邮箱
+	// 验证邮件发送失败不影响注册，用户可以重新发送
+	if p.Email != "" {
+		if err := sendVerifyEmail(userID, p.UserName, p.Email); err != nil {
+			zap.L().Error("sendVerifyEmail failed", zap.Uint64("user_id", userID), zap.Error(err))
+		}
 	}
 	return nil
 }
@@ -0,0 +1,147 @@
+package logic
+
+import (
+	"bluebell_backend/dao/mysql"
+	"bluebell_backend/dao/redis"
+	"bluebell_backend/settings"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+/*
+帖子浏览数
+	* 查看帖子详情时记录浏览：登录用户按用户ID，未登录用户按IP的HMAC识别访客，密钥每天更换，每篇帖子每天用HyperLogLog统计独立访客数
+	* 后台任务定时将新增的浏览数累加到MySQL，帖子详情和列表返回的view_num来自MySQL，最多延迟一个同步间隔
+	* 配置了view_score时，发布一周内的帖子每新增一个访客增加相应的热度分数
+*/
+
+const (
+	defaultViewFlushInterval = time.Minute
+	viewFlushBatchSize       = 100        // 每次从redis取出的帖子数
+	viewSaltDateLayout       = "20060102" // 与redis中浏览记录的日期格式相同，日期变化时更换密钥
+)
+
+// viewSalt 缓存当天计算访客IP哈希值的密钥
+var viewSalt struct {
+	sync.Mutex
+	date string
+	salt string
+}
+
+// hashVisitorIP 使用当天的密钥计算访客IP的HMAC，不保存可以按IP还原的哈希值
+func hashVisitorIP(ip string, now time.Time) (string, error) {
+	viewSalt.Lock()
+	defer viewSalt.Unlock()
+	if viewSalt.date != now.Format(viewSaltDateLayout) {
+		candidate, err := randomToken()
+		if err != nil {
+			return "", err
+		}
+		date, salt, err := redis.GetViewSalt(now, candidate)
+		if err != nil {
+			return "", err
+		}
+		viewSalt.date, viewSalt.salt = date, salt
+	}
+	mac := hmac.New(sha256.New, []byte(viewSalt.salt))
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+// RecordPostView 记录帖子浏览，userID为0表示未登录，此时按IP识别访客
+func RecordPostView(postID, userID uint64, ip string) {
+	now := time.Now()
+	visitor := "u:" + strconv.FormatUint(userID, 10)
+	if userID == 0 {
+		sum, err := hashVisitorIP(ip, now)
+		if err != nil {
+			zap.L().Error("hashVisitorIP failed", zap.Error(err))
+			return
+		}
+		visitor = "ip:" + sum
+	}
+	if err := redis.RecordPostView(postID, visitor, now); err != nil {
+		zap.L().Error("redis.RecordPostView failed", zap.Uint64("post_id", postID), zap.Error(err))
+	}
+}
+
+// flushPostViews 将redis中新增的浏览数同步到MySQL
+func flushPostViews(interval time.Duration) (err error) {
+	token, err := randomToken()
+	if err != nil {
+		return
+	}
+	// 多个实例同时运行时只有获得锁的实例同步
+	locked, err := redis.TryLock(redis.KeyPostViewFlushLock, token, interval)
+	if err != nil || !locked {
+		return
+	}
+	defer func() {
+		if err := redis.Unlock(redis.KeyPostViewFlushLock, token); err != nil {
+			zap.L().Warn("redis.Unlock failed", zap.Error(err))
+		}
+	}()
+
+	for _, date := range redis.ViewDates(time.Now()) {
+		for {
+			ids, err := redis.PopViewedPosts(date, viewFlushBatchSize)
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				flushPostView(date, id)
+			}
+			if len(ids) < viewFlushBatchSize {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// flushPostView 同步一篇帖子某天新增的浏览数
+func flushPostView(date, id string) {
+	postID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return
+	}
+	delta, err := redis.TakePostViewDelta(date, id)
+	if err != nil || delta == 0 {
+		if err != nil {
+			zap.L().Error("redis.TakePostViewDelta failed", zap.String("post_id", id), zap.Error(err))
+		}
+		return
+	}
+	if err := mysql.AddPostViews(postID, delta); err != nil {
+		zap.L().Error("mysql.AddPostViews failed", zap.String("post_id", id), zap.Error(err))
+		if err := redis.RestorePostViewDelta(date, id, delta); err != nil {
+			zap.L().Error("redis.RestorePostViewDelta failed", zap.String("post_id", id), zap.Error(err))
+		}
+		return
+	}
+	if score := settings.Conf.ViewScore; score > 0 {
+		if err := redis.IncrPostViewScore(id, score*float64(delta)); err != nil {
+			zap.L().Error("redis.IncrPostViewScore failed", zap.String("post_id", id), zap.Error(err))
+		}
+	}
+}
+
+// StartPostViewFlusher 启动同步帖子浏览数的后台任务，interval为同步间隔
+func StartPostViewFlusher(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultViewFlushInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := flushPostViews(interval); err != nil {
+			zap.L().Error("flushPostViews failed", zap.Error(err))
+		}
+	}
+}
@@ -36,5 +36,13 @@ func VoteForPost(userId uint64, p *models.VoteDataForm) error {
 		zap.Uint64("userId", userId),
 		zap.String("postId", p.PostID),
 		zap.Int8("Direction", p.Direction))
+	postID, err := strconv.ParseUint(p.PostID, 10, 64)
+	if err != nil {
+		return ErrorPostNotExist
+	}
+	// 锁定的帖子不允许投票
+	if err := checkPostUnlocked(postID); err != nil {
+		return err
+	}
 	return redis.VoteForPost(strconv.Itoa(int(userId)), p.PostID, float64(p.Direction))
 }
@@ -5,12 +5,17 @@ import (
 	"bluebell_backend/dao/mysql"
 	"bluebell_backend/dao/redis"
 	"bluebell_backend/logger"
+	"bluebell_backend/logic"
+	"bluebell_backend/pkg/jwt"
+	"bluebell_backend/pkg/password"
 	"bluebell_backend/pkg/rabbitmq"
 	"bluebell_backend/pkg/snowflake"
+	"bluebell_backend/pkg/storage"
 	"bluebell_backend/routers"
 	"bluebell_backend/settings"
 	"fmt"
 	"go.uber.org/zap"
+	"time"
 )
 
 func main() {
@@ -44,6 +49,21 @@ func main() {
 		fmt.Printf("init snowflake failed, err:%v\n", err)
 		return
 	}
+	// 密码哈希算法
+	if err := password.Init(settings.Conf.PasswordConfig); err != nil {
+		fmt.Printf("init password hasher failed, err:%v\n", err)
+		return
+	}
+	// JWT签名密钥
+	if err := jwt.Init(settings.Conf.AuthConfig); err != nil {
+		fmt.Printf("init jwt failed, err:%v\n", err)
+		return
+	}
+	// 文件存储
+	if err := storage.Init(settings.Conf.StorageConfig); err != nil {
+		fmt.Printf("init storage failed, err:%v\n", err)
+		return
+	}
 	// 翻译器
 	if err := controller.InitTrans("zh"); err != nil {
 		fmt.Printf("init validator Trans failed,err:%v\n", err)
@@ -52,9 +72,21 @@ func main() {
 
 	// 启动消费者
 	go rabbitmq.Consumer()
+	// 启动评论通知的消费者
+	go rabbitmq.NotificationConsumer(logic.DeliverNotifications)
+	// 启动定时发布帖子的后台任务
+	go logic.StartPostPublisher(time.Duration(settings.Conf.PublishInterval) * time.Second)
+	// 启动清理未关联附件的后台任务
+	go logic.StartAttachmentGC()
+	// 启动截止帖子投票的后台任务，与定时发布使用相同的扫描间隔
+	go logic.StartPollCloser(time.Duration(settings.Conf.PublishInterval) * time.Second)
+	// 启动同步帖子浏览数的后台任务
+	go logic.StartPostViewFlusher(time.Duration(settings.Conf.ViewFlushInterval) * time.Second)
+	// 启动校正帖子评论数的后台任务
+	go logic.StartCommentReconciler()
 
 	// 3.注册路由
-	r := routers.SetupRouter(settings.Conf.Mode)
+	r := routers.SetupRouter(settings.Conf.Mode, settings.Conf.TrustedProxies)
 	err := r.Run(fmt.Sprintf(":%d", settings.Conf.Port))
 	if err != nil {
 		fmt.Printf("run server failed, err:%v\n", err)
@@ -2,11 +2,14 @@ package middlewares
 
 import (
 	"bluebell_backend/controller"
+	"bluebell_backend/dao/mysql"
+	"bluebell_backend/dao/redis"
 	"bluebell_backend/pkg/jwt"
-	"fmt"
+	"bluebell_backend/settings"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // JWTAuthMiddleware JWT的认证中间件，实现鉴权
@@ -21,33 +24,97 @@ func JWTAuthMiddleware() func(c *gin.Context) {
 			c.Abort()
 			return
 		}
-
-		// 2.解析得到tokenString
-		// parts[0]=="Bearer", parts[1]==tokenString
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			controller.ResponseErrorWithMsg(c, controller.CodeInvalidToken, "Token格式错误，应为Bearer <token>")
+		mc, code, msg := authenticate(authHeader)
+		if mc == nil {
+			if msg != "" {
+				controller.ResponseErrorWithMsg(c, code, msg)
+			} else {
+				controller.ResponseError(c, code)
+			}
 			c.Abort()
 			return
 		}
 
-		// 3.解析并验证JWT
-		mc, err := jwt.ParseToken(parts[1])
+		// 6.将claims中的userID信息保存到请求的上下文c
+		c.Set(controller.ContextUserIDKey, mc.UserID)
+		c.Set(controller.ContextClaimsKey, mc)
+		c.Next() // 后续的处理函数可以用过c.Get(ContextUserIDKey)来获取当前请求的用户信息
+	}
+}
+
+// OptionalJWTAuthMiddleware 可选的认证中间件：请求携带有效Token时保存当前用户信息，否则按未登录用户继续处理
+func OptionalJWTAuthMiddleware() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		if authHeader := c.Request.Header.Get("Authorization"); authHeader != "" {
+			if mc, _, _ := authenticate(authHeader); mc != nil {
+				c.Set(controller.ContextUserIDKey, mc.UserID)
+				c.Set(controller.ContextClaimsKey, mc)
+			}
+		}
+		c.Next()
+	}
+}
+
+// authenticate 解析并校验Authorization请求头中的Access Token，校验失败时mc为nil并返回响应状态码及提示
+func authenticate(authHeader string) (mc *jwt.MyClaims, code controller.MyCode, msg string) {
+	// 2.解析得到tokenString
+	// parts[0]=="Bearer", parts[1]==tokenString
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, controller.CodeInvalidToken, "Token格式错误，应为Bearer <token>"
+	}
+
+	// 3.解析并验证JWT
+	mc, err := jwt.ParseToken(parts[1])
+	if err != nil {
+		zap.L().Debug("jwt.ParseToken failed", zap.Error(err))
+		return nil, controller.CodeInvalidToken, ""
+	}
+
+	// 4.已注销(吊销)的Token不能再使用
+	revoked, err := redis.IsTokenRevoked(mc.Id)
+	if err != nil {
+		zap.L().Error("redis.IsTokenRevoked failed", zap.String("jti", mc.Id), zap.Error(err))
+		return nil, controller.CodeServerBusy, ""
+	}
+	if revoked {
+		return nil, controller.CodeTokenRevoked, ""
+	}
+
+	// 5.限制账号同时登录的设备数：当前请求Token必须是Redis中登记的有效Token
+	ok, err := redis.CheckSession(mc.UserID, mc.Id)
+	if err != nil {
+		zap.L().Error("redis.CheckSession failed", zap.Uint64("user_id", mc.UserID), zap.Error(err))
+		return nil, controller.CodeServerBusy, ""
+	}
+	if !ok {
+		return nil, controller.CodeLoginElsewhere, ""
+	}
+	return mc, 0, ""
+}
+
+// VerifiedEmailMiddleware 开启require_verified_email时，邮箱未验证的用户不能发帖、评论、投票
+// 需在JWTAuthMiddleware之后使用
+func VerifiedEmailMiddleware() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		if settings.Conf.AuthConfig == nil || !settings.Conf.RequireVerifiedEmail {
+			c.Next()
+			return
+		}
+		userID, _ := c.Get(controller.ContextUserIDKey)
+		uid, _ := userID.(uint64)
+		verified, err := mysql.IsEmailVerified(uid)
 		if err != nil {
-			fmt.Println(err)
-			controller.ResponseError(c, controller.CodeInvalidToken)
+			zap.L().Error("mysql.IsEmailVerified failed", zap.Uint64("user_id", uid), zap.Error(err))
+			controller.ResponseError(c, controller.CodeServerBusy)
 			c.Abort()
 			return
 		}
-
-		/*
-			限制账号在同一时间只能在一个设备上登录
-			* 从Redis中获取 userID 对应的 Token
-			* 比较 当前请求Token 与 Redis中的Token 是否一致
-		*/
-
-		// 4.将claims中的userID信息保存到请求的上下文c
-		c.Set(controller.ContextUserIDKey, mc.UserID)
-		c.Next() // 后续的处理函数可以用过c.Get(ContextUserIDKey)来获取当前请求的用户信息
+		if !verified {
+			controller.ResponseError(c, controller.CodeEmailNotVerified)
+			c.Abort()
+			return
+		}
+		c.Next()
 	}
 }
@@ -0,0 +1,80 @@
+package middlewares
+
+import (
+	"bluebell_backend/controller"
+	"bluebell_backend/dao/mysql"
+	"bluebell_backend/models"
+	"bluebell_backend/pkg/jwt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// currentClaims 获取JWTAuthMiddleware保存的Token负载
+func currentClaims(c *gin.Context) *jwt.MyClaims {
+	claims, _ := c.Get(controller.ContextClaimsKey)
+	mc, _ := claims.(*jwt.MyClaims)
+	return mc
+}
+
+// RequireRole 角色鉴权，用户角色的权限不低于role才能访问，需在JWTAuthMiddleware之后使用
+// 例如 RequireRole("moderator") 允许版主和管理员访问
+func RequireRole(role string) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		mc := currentClaims(c)
+		if mc == nil {
+			controller.ResponseError(c, controller.CodeNotLogin)
+			c.Abort()
+			return
+		}
+		if !models.RoleAtLeast(mc.Role, role) {
+			controller.ResponseError(c, controller.CodeNoPermission)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireCommunityModerator 社区版主鉴权，社区ID取自路径参数param，管理员可以管理所有社区
+// 需在JWTAuthMiddleware之后使用
+func RequireCommunityModerator(param string) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		mc := currentClaims(c)
+		if mc == nil {
+			controller.ResponseError(c, controller.CodeNotLogin)
+			c.Abort()
+			return
+		}
+		if mc.Role == models.RoleAdmin {
+			c.Next()
+			return
+		}
+		communityID, err := strconv.ParseUint(c.Param(param), 10, 64)
+		if err != nil {
+			controller.ResponseError(c, controller.CodeInvalidParams)
+			c.Abort()
+			return
+		}
+		if mc.Role != models.RoleModerator {
+			controller.ResponseError(c, controller.CodeNoPermission)
+			c.Abort()
+			return
+		}
+		ok, err := mysql.IsCommunityModerator(communityID, mc.UserID)
+		if err != nil {
+			zap.L().Error("mysql.IsCommunityModerator failed",
+				zap.Uint64("community_id", communityID), zap.Uint64("user_id", mc.UserID), zap.Error(err))
+			controller.ResponseError(c, controller.CodeServerBusy)
+			c.Abort()
+			return
+		}
+		if !ok {
+			controller.ResponseError(c, controller.CodeNoPermission)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,3 @@
+-- 已有数据库升级：密码改用argon2id哈希存储，编码后长度超过64
+-- 新建数据库直接执行create_tables.sql即可，无需执行本文件
+ALTER TABLE `user` MODIFY `password` varchar(255) COLLATE utf8mb4_general_ci NOT NULL;
@@ -0,0 +1,2 @@
+-- 已有数据库升级：邮箱验证状态
+ALTER TABLE `user` ADD COLUMN `email_verified` tinyint(1) NOT NULL DEFAULT '0' AFTER `email`;
@@ -0,0 +1,5 @@
+-- 已有数据库升级：用户资料
+ALTER TABLE `user`
+  ADD COLUMN `display_name` varchar(64) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' AFTER `gender`,
+  ADD COLUMN `bio` varchar(512) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' AFTER `display_name`,
+  ADD COLUMN `avatar_url` varchar(256) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' AFTER `bio`;
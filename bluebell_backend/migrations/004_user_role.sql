@@ -0,0 +1,14 @@
+-- 已有数据库升级：用户角色和社区版主
+ALTER TABLE `user` ADD COLUMN `role` varchar(16) COLLATE utf8mb4_general_ci NOT NULL DEFAULT 'user' COMMENT '角色 user/moderator/admin' AFTER `gender`;
+
+CREATE TABLE IF NOT EXISTS `community_moderator` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `community_id` int(10) unsigned NOT NULL COMMENT '社区id',
+  `user_id` bigint(20) NOT NULL COMMENT '版主的用户id',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_community_user` (`community_id`,`user_id`),
+  KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- 初始化管理员：update user set role = 'admin' where username = '<username>';
@@ -0,0 +1,15 @@
+-- 已有数据库升级：两步验证
+ALTER TABLE `user`
+  ADD COLUMN `totp_secret` varchar(64) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '两步验证密钥' AFTER `gender`,
+  ADD COLUMN `totp_enabled` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否开启两步验证' AFTER `totp_secret`,
+  ADD COLUMN `totp_last_counter` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT '最近一次使用的验证码时间步' AFTER `totp_enabled`;
+
+CREATE TABLE IF NOT EXISTS `user_recovery_code` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `user_id` bigint(20) NOT NULL,
+  `code_hash` char(64) COLLATE utf8mb4_general_ci NOT NULL COMMENT '恢复码的sha256',
+  `used_time` timestamp NULL DEFAULT NULL COMMENT '使用时间，未使用为NULL',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_user_code` (`user_id`,`code_hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
@@ -0,0 +1,12 @@
+-- 已有数据库升级：帖子编辑历史
+CREATE TABLE IF NOT EXISTS `post_revision` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `post_id` bigint(20) NOT NULL COMMENT '帖子id',
+  `revision` int(11) NOT NULL COMMENT '版本号，从1开始',
+  `title` varchar(128) COLLATE utf8mb4_general_ci NOT NULL COMMENT '标题',
+  `content` varchar(8192) COLLATE utf8mb4_general_ci NOT NULL COMMENT '内容',
+  `editor_id` bigint(20) NOT NULL COMMENT '编辑者的用户id',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_post_revision` (`post_id`,`revision`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
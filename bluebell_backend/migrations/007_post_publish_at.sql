@@ -0,0 +1,5 @@
+-- 已有数据库升级：草稿和定时发布
+ALTER TABLE `post`
+  MODIFY `status` tinyint(4) NOT NULL DEFAULT '1' COMMENT '帖子状态 0:已删除 1:已发布 2:草稿 3:定时发布',
+  ADD COLUMN `publish_at` timestamp NULL DEFAULT NULL COMMENT '发布时间' AFTER `status`,
+  ADD KEY `idx_status_publish_at` (`status`,`publish_at`);
@@ -0,0 +1,10 @@
+-- 已有数据库升级：帖子标签
+CREATE TABLE IF NOT EXISTS `post_tag` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `post_id` bigint(20) NOT NULL COMMENT '帖子id',
+  `tag_name` varchar(32) COLLATE utf8mb4_general_ci NOT NULL COMMENT '标签，已规范化为小写',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_post_tag` (`post_id`,`tag_name`),
+  KEY `idx_tag_name` (`tag_name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
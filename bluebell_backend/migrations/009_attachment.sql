@@ -0,0 +1,18 @@
+-- 已有数据库升级：图片和附件上传
+CREATE TABLE IF NOT EXISTS `attachment` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `attachment_id` bigint(20) NOT NULL COMMENT '附件id',
+  `user_id` bigint(20) NOT NULL COMMENT '上传者的用户id',
+  `post_id` bigint(20) NOT NULL DEFAULT '0' COMMENT '关联的帖子id，0表示未关联',
+  `file_name` varchar(255) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '上传时的文件名',
+  `content_type` varchar(64) COLLATE utf8mb4_general_ci NOT NULL COMMENT '文件类型',
+  `size` bigint(20) NOT NULL COMMENT '文件大小(字节)',
+  `width` int(11) NOT NULL DEFAULT '0' COMMENT '图片宽度',
+  `height` int(11) NOT NULL DEFAULT '0' COMMENT '图片高度',
+  `storage_key` varchar(255) COLLATE utf8mb4_general_ci NOT NULL COMMENT '文件在存储中的key',
+  `thumb_key` varchar(255) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '缩略图在存储中的key',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_attachment_id` (`attachment_id`),
+  KEY `idx_post_id_create_time` (`post_id`,`create_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
@@ -0,0 +1,5 @@
+-- 已有数据库升级：置顶、锁定、精华帖
+ALTER TABLE `post`
+  ADD COLUMN `pinned` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否在社区中置顶' AFTER `publish_at`,
+  ADD COLUMN `locked` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否锁定，锁定后不允许评论和投票' AFTER `pinned`,
+  ADD COLUMN `featured` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否精华帖' AFTER `locked`;
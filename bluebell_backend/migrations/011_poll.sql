@@ -0,0 +1,23 @@
+-- 已有数据库升级：帖子投票
+CREATE TABLE IF NOT EXISTS `poll` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `post_id` bigint(20) NOT NULL COMMENT '帖子id，每篇帖子最多一个投票',
+  `multiple` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否允许多选',
+  `close_at` timestamp NULL DEFAULT NULL COMMENT '截止时间',
+  `closed` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否已截止，截止后结果保存在poll_option中',
+  `voters` int(11) NOT NULL DEFAULT '0' COMMENT '截止时参与投票的人数',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_post_id` (`post_id`),
+  KEY `idx_closed_close_at` (`closed`,`close_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+CREATE TABLE IF NOT EXISTS `poll_option` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `post_id` bigint(20) NOT NULL COMMENT '帖子id',
+  `option_index` tinyint(4) NOT NULL COMMENT '选项序号，从0开始',
+  `content` varchar(128) COLLATE utf8mb4_general_ci NOT NULL COMMENT '选项内容',
+  `votes` int(11) NOT NULL DEFAULT '0' COMMENT '截止时的得票数',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_post_option` (`post_id`,`option_index`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
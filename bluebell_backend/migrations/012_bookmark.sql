@@ -0,0 +1,22 @@
+-- 已有数据库升级：收藏和收藏夹
+CREATE TABLE IF NOT EXISTS `bookmark_collection` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `collection_id` bigint(20) NOT NULL COMMENT '收藏夹id',
+  `user_id` bigint(20) NOT NULL COMMENT '用户id',
+  `name` varchar(32) COLLATE utf8mb4_general_ci NOT NULL COMMENT '收藏夹名称',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_collection_id` (`collection_id`),
+  UNIQUE KEY `idx_user_name` (`user_id`,`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+CREATE TABLE IF NOT EXISTS `bookmark` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `user_id` bigint(20) NOT NULL COMMENT '用户id',
+  `post_id` bigint(20) NOT NULL COMMENT '帖子id',
+  `collection_id` bigint(20) NOT NULL DEFAULT '0' COMMENT '所属收藏夹id，0表示未放入收藏夹',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '收藏时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_user_post` (`user_id`,`post_id`),
+  KEY `idx_user_collection_time` (`user_id`,`collection_id`,`create_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
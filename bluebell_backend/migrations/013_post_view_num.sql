@@ -0,0 +1,2 @@
+-- 已有数据库升级：帖子浏览数
+ALTER TABLE `post` ADD COLUMN `view_num` bigint(20) NOT NULL DEFAULT '0' COMMENT '浏览数(每天按独立访客计数)' AFTER `featured`;
@@ -0,0 +1,2 @@
+-- 已有数据库升级：按帖子和父评论查询评论树
+ALTER TABLE `comment` ADD KEY `idx_post_parent_time` (`post_id`,`parent_id`,`create_time`);
@@ -0,0 +1,4 @@
+-- 已有数据库升级：评论编辑和软删除
+ALTER TABLE `comment`
+  MODIFY `status` tinyint(3) unsigned NOT NULL DEFAULT '1' COMMENT '评论状态 0:已删除 1:正常',
+  ADD COLUMN `edit_time` timestamp NULL DEFAULT NULL COMMENT '最后编辑时间' AFTER `status`;
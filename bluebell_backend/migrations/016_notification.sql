@@ -0,0 +1,16 @@
+-- 已有数据库升级：回复和@提及通知
+CREATE TABLE IF NOT EXISTS `notification` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `notification_id` bigint(20) NOT NULL COMMENT '通知id',
+  `user_id` bigint(20) NOT NULL COMMENT '接收通知的用户id',
+  `type` varchar(16) COLLATE utf8mb4_general_ci NOT NULL COMMENT '通知类型 reply_post/reply_comment/mention',
+  `actor_id` bigint(20) NOT NULL COMMENT '发表评论的用户id',
+  `post_id` bigint(20) NOT NULL COMMENT '帖子id',
+  `comment_id` bigint(20) NOT NULL COMMENT '评论id',
+  `is_read` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否已读',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_notification_id` (`notification_id`),
+  UNIQUE KEY `idx_user_comment` (`user_id`,`comment_id`),
+  KEY `idx_user_read_time` (`user_id`,`is_read`,`create_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
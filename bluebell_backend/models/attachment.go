@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Attachment 上传的图片或附件，post_id为0表示尚未关联帖子
+type Attachment struct {
+	AttachmentID uint64    `json:"attachment_id,string" db:"attachment_id"`
+	UserID       uint64    `json:"user_id,string" db:"user_id"`
+	PostID       uint64    `json:"post_id,string" db:"post_id"`
+	FileName     string    `json:"file_name" db:"file_name"`       // 上传时的文件名
+	ContentType  string    `json:"content_type" db:"content_type"` // 按文件内容识别的MIME类型
+	Size         int64     `json:"size" db:"size"`                 // 文件大小(字节)
+	Width        int       `json:"width,omitempty" db:"width"`     // 图片宽度
+	Height       int       `json:"height,omitempty" db:"height"`   // 图片高度
+	StorageKey   string    `json:"-" db:"storage_key"`
+	ThumbKey     string    `json:"-" db:"thumb_key"`
+	URL          string    `json:"url" db:"-"`
+	ThumbURL     string    `json:"thumbnail_url,omitempty" db:"-"`
+	CreateTime   time.Time `json:"create_time" db:"create_time"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Bookmark 用户收藏的帖子
+type Bookmark struct {
+	UserID       uint64    `db:"user_id"`
+	PostID       uint64    `db:"post_id"`
+	CollectionID uint64    `db:"collection_id"`
+	CreateTime   time.Time `db:"create_time"`
+}
+
+// BookmarkCollection 用户的收藏夹
+type BookmarkCollection struct {
+	CollectionID uint64    `json:"collection_id,string" db:"collection_id"`
+	UserID       uint64    `json:"-" db:"user_id"`
+	Name         string    `json:"name" db:"name"`
+	PostCount    int64     `json:"post_count" db:"post_count"` // 收藏夹中的帖子数量
+	CreateTime   time.Time `json:"create_time" db:"create_time"`
+}
+
+// ParamBookmark 定义收藏帖子时的请求参数
+type ParamBookmark struct {
+	CollectionID uint64 `json:"collection_id,string"` // 收藏到的收藏夹，为空时不放入任何收藏夹
+}
+
+// ParamCollection 定义创建收藏夹时的请求参数
+type ParamCollection struct {
+	Name string `json:"name" binding:"required,max=32"`
+}
+
+// ParamBookmarkList 定义查询收藏列表时的请求参数
+type ParamBookmarkList struct {
+	CollectionID uint64 `form:"collection_id"` // 只查询某个收藏夹中的帖子
+	Page         int64  `form:"page"`
+	Size         int64  `form:"size"`
+}
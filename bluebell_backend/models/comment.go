@@ -3,10 +3,70 @@ package models
 import "time"
 
 type Comment struct {
-	PostID     uint64    `db:"post_id" json:"post_id"`
-	ParentID   uint64    `db:"parent_id" json:"parent_id"`
-	CommentID  uint64    `db:"comment_id" json:"comment_id"`
-	AuthorID   uint64    `db:"author_id" json:"author_id"`
-	Content    string    `db:"content" json:"content"`
-	CreateTime time.Time `db:"create_time" json:"create_time"`
+	PostID      uint64     `db:"post_id" json:"post_id,string"`
+	ParentID    uint64     `db:"parent_id" json:"parent_id,string"`
+	CommentID   uint64     `db:"comment_id" json:"comment_id,string"`
+	AuthorID    uint64     `db:"author_id" json:"author_id,string"`
+	Content     string     `db:"content" json:"content"`
+	ContentHTML string     `db:"-" json:"content_html"` // 评论内容按Markdown渲染并过滤后的HTML
+	Status      int8       `db:"status" json:"-"`
+	Deleted     bool       `db:"-" json:"deleted"`                     // 已删除的评论在评论树中保留其回复，内容显示为[deleted]
+	EditTime    *time.Time `db:"edit_time" json:"edit_time,omitempty"` // 最后编辑时间，未编辑过时为空
+	CreateTime  time.Time  `db:"create_time" json:"create_time"`
+}
+
+// 评论状态
+const (
+	CommentStatusDeleted = 0 // 已删除
+	CommentStatusNormal  = 1 // 正常
+)
+
+// ParamComment 定义发表评论时的请求参数
+type ParamComment struct {
+	PostID   uint64 `json:"post_id,string" binding:"required"`
+	ParentID uint64 `json:"parent_id,string"` // 回复的评论，为0时为一级评论
+	Content  string `json:"content" binding:"required,max=5000"`
+}
+
+// ParamUpdateComment 定义编辑评论时的请求参数
+type ParamUpdateComment struct {
+	Content string `json:"content" binding:"required,max=5000"`
+}
+
+// ParamCommentTree 获取帖子评论树的query参数
+type ParamCommentTree struct {
+	ParentID uint64 `form:"parent_id"` // 只查询某条评论的回复，用于展开被折叠的回复；为0时查询帖子的一级评论
+	Order    string `form:"order"`     // 排序依据 time/score/best，回复按发布时间升序排列，best时按Wilson得分排列
+	Depth    int    `form:"depth"`     // 返回的评论层数，不超过配置的上限
+	Page     int64  `form:"page"`
+	Size     int64  `form:"size"`
+}
+
+// ApiCommentNode 评论树的节点
+type ApiCommentNode struct {
+	*Comment
+	AuthorName   string            `json:"author_name"`
+	ReplyNum     int64             `json:"reply_num"`     // 直接回复的数量
+	CollapsedNum int64             `json:"collapsed_num"` // 未返回的直接回复数量(超过最大层数或返回数量上限)，通过parent_id分页加载
+	Ups          int64             `json:"ups"`           // 赞成票数
+	Downs        int64             `json:"downs"`         // 反对票数
+	Vote         int8              `json:"vote"`          // 当前用户的投票 1赞成 -1反对 0未投票
+	Replies      []*ApiCommentNode `json:"replies"`
+}
+
+// ApiCommentTree 分页返回的评论树
+type ApiCommentTree struct {
+	Page Page              `json:"page"`
+	List []*ApiCommentNode `json:"list"`
+}
+
+// ParamCommentVote 定义为评论投票时的请求参数
+type ParamCommentVote struct {
+	Direction int8 `json:"direction,string" binding:"oneof=1 0 -1"` // 赞成票(1)还是反对票(-1)取消投票(0)
+}
+
+// PostCommentNum 帖子的评论数量
+type PostCommentNum struct {
+	PostID     uint64 `db:"post_id"`
+	CommentNum int64  `db:"comment_num"`
 }
@@ -16,6 +16,17 @@ type CommunityDetail struct {
 	CreateTime    time.Time `json:"create_time" db:"create_time"`
 }
 
+// ParamCreateCommunity 定义创建社区时的请求参数
+type ParamCreateCommunity struct {
+	CommunityName string `json:"community_name" binding:"required,max=128"`
+	Introduction  string `json:"introduction" binding:"required,max=256"`
+}
+
+// ParamModerator 定义指派版主时的请求参数
+type ParamModerator struct {
+	UserID uint64 `json:"user_id,string" binding:"required"`
+}
+
 // CommunityDetailRes 时间以
 type CommunityDetailRes struct {
 	CommunityID   uint64 `json:"community_id" db:"community_id"`
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// 通知类型
+const (
+	NotificationReplyPost    = "reply_post"    // 评论了用户的帖子
+	NotificationReplyComment = "reply_comment" // 回复了用户的评论
+	NotificationMention      = "mention"       // 在评论中@了用户
+)
+
+// Notification 用户收到的通知
+type Notification struct {
+	NotificationID uint64    `json:"notification_id,string" db:"notification_id"`
+	UserID         uint64    `json:"-" db:"user_id"` // 接收通知的用户
+	Type           string    `json:"type" db:"type"`
+	ActorID        uint64    `json:"actor_id,string" db:"actor_id"` // 发表评论的用户
+	ActorName      string    `json:"actor_name" db:"-"`
+	PostID         uint64    `json:"post_id,string" db:"post_id"`
+	CommentID      uint64    `json:"comment_id,string" db:"comment_id"`
+	Read           bool      `json:"read" db:"is_read"`
+	CreateTime     time.Time `json:"create_time" db:"create_time"`
+}
+
+// NotificationTask 发表评论后发布到消息队列的通知任务
+type NotificationTask struct {
+	PostID    uint64 `json:"post_id,string"`
+	CommentID uint64 `json:"comment_id,string"`
+	ParentID  uint64 `json:"parent_id,string"` // 回复的评论，为0时为一级评论
+	ActorID   uint64 `json:"actor_id,string"`  // 评论作者
+	Content   string `json:"content"`          // 评论内容，用于解析@用户名
+}
+
+// ParamNotificationList 定义查询通知列表时的请求参数
+type ParamNotificationList struct {
+	Unread bool  `form:"unread"` // 只查询未读通知
+	Page   int64 `form:"page"`
+	Size   int64 `form:"size"`
+}
+
+// ApiNotificationList 分页返回的通知列表
+type ApiNotificationList struct {
+	Page      Page            `json:"page"`
+	UnreadNum int64           `json:"unread_num"` // 未读通知数量
+	List      []*Notification `json:"list"`
+}
@@ -2,17 +2,20 @@ package models
 
 const (
 	// 排序规则
-	OrderTime  = "time"
-	OrderScore = "score"
+	OrderTime     = "time"
+	OrderScore    = "score"
+	OrderBest     = "best"     // 评论按赞成率的Wilson得分下界排序
+	OrderComments = "comments" // 帖子按评论数排序(讨论最多)
 )
 
 // ParamPostList 获取帖子列表query 参数
 type ParamPostList struct {
-	Search      string `json:"search" form:"search"` // 关键字搜索
-	CommunityID uint64 `json:"community_id" form:"community_id"`
-	Page        int64  `json:"page" form:"page"`                   // 页码
-	Size        int64  `json:"size" form:"size"`                   // 每页数量
-	Order       string `json:"order" form:"order" example:"score"` // 排序依据
+	Search      string   `json:"search" form:"search"` // 关键字搜索
+	CommunityID uint64   `json:"community_id" form:"community_id"`
+	Page        int64    `json:"page" form:"page"`                   // 页码
+	Size        int64    `json:"size" form:"size"`                   // 每页数量
+	Order       string   `json:"order" form:"order" example:"score"` // 排序依据
+	Tags        []string `json:"tags" form:"tag"`                    // 按标签过滤，多个标签时查询同时包含这些标签的帖子
 }
 
 // ParamGithubTrending 获取Github热榜项目 query 参数
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// ParamPoll 创建帖子时附带的投票
+type ParamPoll struct {
+	Options  []string   `json:"options"`  // 选项，2~10个
+	Multiple bool       `json:"multiple"` // 是否允许多选
+	CloseAt  *time.Time `json:"close_at"` // 截止时间，为空时不自动截止
+}
+
+// ParamPollVote 定义参与投票时的请求参数
+type ParamPollVote struct {
+	Options []int `json:"options" binding:"required,min=1,dive,min=0"` // 选择的选项序号，从0开始
+}
+
+// Poll 帖子的投票
+type Poll struct {
+	PostID   uint64     `json:"-" db:"post_id"`
+	Multiple bool       `json:"multiple" db:"multiple"`
+	CloseAt  *time.Time `json:"close_at,omitempty" db:"close_at"`
+	Closed   bool       `json:"closed" db:"closed"`
+	Voters   int64      `json:"voters" db:"voters"` // 参与投票的人数
+}
+
+// PollOption 投票的选项
+type PollOption struct {
+	PostID  uint64 `json:"-" db:"post_id"`
+	Index   int    `json:"index" db:"option_index"`
+	Content string `json:"content" db:"content"`
+	Votes   int64  `json:"votes" db:"votes"`
+}
+
+// ApiPoll 帖子详情中返回的投票及结果
+type ApiPoll struct {
+	*Poll
+	Options []*PollOption `json:"options"`
+	Voted   []int         `json:"voted,omitempty"` // 当前用户选择的选项
+}
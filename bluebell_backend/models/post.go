@@ -3,27 +3,52 @@ package models
 import (
 	"encoding/json"
 	"errors"
+	"strconv"
 	"time"
 )
 
 // Post 帖子Post结构体 内存对齐概念 字段类型相同的对齐 缩小变量所占内存大小
 type Post struct {
-	PostID      uint64    `json:"post_id,string" db:"post_id"`
-	AuthorId    uint64    `json:"author_id" db:"author_id"`
-	CommunityID uint64    `json:"community_id" db:"community_id" binding:"required"`
-	Status      int32     `json:"status" db:"status"`
-	Title       string    `json:"title" db:"title" binding:"required"`
-	Content     string    `json:"content" db:"content" binding:"required"`
-	CreateTime  time.Time `json:"-" db:"create_time"`
-	UpdateTime  time.Time `json:"-" db:"update_time"`
+	PostID      uint64     `json:"post_id,string" db:"post_id"`
+	AuthorId    uint64     `json:"author_id" db:"author_id"`
+	CommunityID uint64     `json:"community_id" db:"community_id" binding:"required"`
+	Status      int32      `json:"status" db:"status"`
+	Pinned      bool       `json:"pinned" db:"pinned"`     // 在社区帖子列表中置顶
+	Locked      bool       `json:"locked" db:"locked"`     // 锁定后不允许评论和投票
+	Featured    bool       `json:"featured" db:"featured"` // 精华帖
+	ViewNum     int64      `json:"view_num" db:"view_num"` // 浏览数，定时从redis同步
+	Title       string     `json:"title" db:"title" binding:"required"`
+	Content     string     `json:"content" db:"content" binding:"required"`
+	Draft       bool       `json:"-" db:"-"`                             // 创建时保存为草稿
+	PublishAt   *time.Time `json:"publish_at,omitempty" db:"publish_at"` // 发布时间，定时发布的帖子为计划发布时间
+	Tags        []string   `json:"tags,omitempty" db:"-"`                // 帖子标签
+	// 创建帖子时关联的附件，附件需由作者上传且尚未关联其他帖子
+	AttachmentIDs []uint64 `json:"-" db:"-"`
+	// 创建帖子时附带的投票
+	Poll       *ParamPoll `json:"-" db:"-"`
+	CreateTime time.Time  `json:"-" db:"create_time"`
+	UpdateTime time.Time  `json:"-" db:"update_time"`
 }
 
+// 帖子状态
+const (
+	PostStatusDeleted   = 0 // 已删除
+	PostStatusPublished = 1 // 已发布
+	PostStatusDraft     = 2 // 草稿，只有作者可见
+	PostStatusScheduled = 3 // 定时发布，到达publish_at后由后台任务发布
+)
+
 // UnmarshalJSON 为Post类型实现自定义的UnmarshalJSON方法
 func (p *Post) UnmarshalJSON(data []byte) (err error) {
 	required := struct {
-		Title       string `json:"title" db:"title"`
-		Content     string `json:"content" db:"content"`
-		CommunityID int64  `json:"community_id" db:"community_id"`
+		Title       string     `json:"title" db:"title"`
+		Content     string     `json:"content" db:"content"`
+		CommunityID int64      `json:"community_id" db:"community_id"`
+		Draft       bool       `json:"draft"`      // 保存为草稿
+		PublishAt   *time.Time `json:"publish_at"` // 定时发布时间 RFC3339格式
+		Tags        []string   `json:"tags"`
+		Attachments []string   `json:"attachment_ids"`
+		Poll        *ParamPoll `json:"poll"`
 	}{}
 	err = json.Unmarshal(data, &required)
 	if err != nil {
@@ -38,16 +63,93 @@ func (p *Post) UnmarshalJSON(data []byte) (err error) {
 		p.Title = required.Title
 		p.Content = required.Content
 		p.CommunityID = uint64(required.CommunityID)
+		p.Draft = required.Draft
+		p.PublishAt = required.PublishAt
+		p.Tags = required.Tags
+		p.Poll = required.Poll
+		for _, id := range required.Attachments {
+			attachmentID, err := strconv.ParseUint(id, 10, 64)
+			if err != nil {
+				return errors.New("attachment_ids中包含无效的附件id")
+			}
+			p.AttachmentIDs = append(p.AttachmentIDs, attachmentID)
+		}
 	}
 	return
 }
 
+// ParamUpdatePost 定义编辑帖子时的请求参数，community_id为0时不修改所属社区
+type ParamUpdatePost struct {
+	Title       string   `json:"title" binding:"required,max=128"`
+	Content     string   `json:"content" binding:"required,max=8192"`
+	CommunityID uint64   `json:"community_id"`
+	Tags        []string `json:"tags"` // 为空时不修改帖子标签，传入空数组时清空标签
+	// 新关联到帖子的附件
+	AttachmentIDs []string `json:"attachment_ids" binding:"dive,numeric"`
+}
+
+// ParamPostFlags 定义版主设置帖子置顶、锁定、精华时的请求参数，为空的字段不修改
+type ParamPostFlags struct {
+	Pinned   *bool `json:"pinned"`
+	Locked   *bool `json:"locked"`
+	Featured *bool `json:"featured"`
+}
+
+// ParamPublishPost 定义发布草稿时的请求参数，publish_at为空或已过去时立即发布
+type ParamPublishPost struct {
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// PostRevision 帖子的一个历史版本
+type PostRevision struct {
+	PostID     uint64    `json:"post_id,string" db:"post_id"`
+	Revision   int64     `json:"revision" db:"revision"` // 版本号，从1开始
+	Title      string    `json:"title" db:"title"`
+	Content    string    `json:"content,omitempty" db:"content"`
+	EditorID   uint64    `json:"editor_id,string" db:"editor_id"` // 编辑者
+	CreateTime time.Time `json:"create_time" db:"create_time"`
+}
+
+// ParamRevisionDiff 定义比较两个版本时的请求参数
+type ParamRevisionDiff struct {
+	From int64  `form:"from" binding:"required,min=1"`
+	To   int64  `form:"to" binding:"required,min=1"`
+	Mode string `form:"mode" binding:"omitempty,oneof=line word"` // 内容的比较粒度，默认按行
+}
+
+// 差异片段类型
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// DiffOp 差异片段
+type DiffOp struct {
+	Op   string `json:"op"` // equal / insert / delete
+	Text string `json:"text"`
+}
+
+// ApiRevisionDiff 两个版本的差异
+type ApiRevisionDiff struct {
+	From    int64     `json:"from"`
+	To      int64     `json:"to"`
+	Mode    string    `json:"mode"`
+	Title   []*DiffOp `json:"title"`   // 标题按词比较
+	Content []*DiffOp `json:"content"` // 内容按行或按词比较
+}
+
 // ApiPostDetail 帖子返回的详情信息结构体
 type ApiPostDetail struct {
 	*Post                                  // 内嵌帖子结构体
 	*CommunityDetailRes `json:"community"` // 内嵌社区详情结构体
 	AuthorName          string             `json:"author_name"`
-	VoteNum             int64              `json:"vote_num"` // 投票数量
+	VoteNum             int64              `json:"vote_num"`               // 投票数量
+	CommentNum          int64              `json:"comment_num"`            // 评论数量
+	ContentHTML         string             `json:"content_html,omitempty"` // 帖子内容按Markdown渲染并过滤后的HTML
+	Attachments         []*Attachment      `json:"attachments,omitempty"`  // 帖子的图片和附件
+	Poll                *ApiPoll           `json:"poll,omitempty"`         // 帖子的投票及结果
+	Bookmarked          bool               `json:"bookmarked"`             // 当前用户是否收藏了帖子，未登录时为false
 	//CommunityName string `json:"community_name"`
 }
 
@@ -0,0 +1,13 @@
+package models
+
+// ApiTag 标签及其已发布帖子数量
+type ApiTag struct {
+	Name      string `json:"name"`
+	PostCount int64  `json:"post_count"`
+}
+
+// ApiTagDetail 标签页：标签信息及该标签下的帖子列表
+type ApiTagDetail struct {
+	*ApiTag
+	Posts *ApiPostDetailRes `json:"posts"`
+}
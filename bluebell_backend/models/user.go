@@ -3,17 +3,22 @@ package models
 import (
 	"encoding/json"
 	"errors"
+	"time"
 )
 
 // User 结构体
 type User struct {
-	UserID       uint64 `json:"user_id,string" db:"user_id"` // 指定json序列化/反序列化时使用小写user_id
-	UserName     string `json:"username" db:"username"`
-	Password     string `json:"password" db:"password"`
-	Email        string `json:"email" db:"gender"`  // 邮箱
-	Gender       int    `json:"gender" db:"gender"` // 性别
-	AccessToken  string
-	RefreshToken string
+	UserID         uint64 `json:"user_id,string" db:"user_id"` // 指定json序列化/反序列化时使用小写user_id
+	UserName       string `json:"username" db:"username"`
+	Password       string `json:"password" db:"password"`
+	Email          string `json:"email" db:"email"`                   // 邮箱
+	Gender         int    `json:"gender" db:"gender"`                 // 性别
+	EmailVerified  bool   `json:"email_verified" db:"email_verified"` // 邮箱是否已验证
+	Role           string `json:"role" db:"role"`                     // 用户角色
+	TOTPEnabled    bool   `json:"totp_enabled" db:"totp_enabled"`     // 是否开启两步验证
+	AccessToken    string
+	RefreshToken   string
+	TwoFactorToken string // 开启两步验证时，登录第一步返回的临时凭证
 }
 
 // UnmarshalJSON 为User类型实现自定义的UnmarshalJSON方法
@@ -40,6 +45,41 @@ func (u *User) UnmarshalJSON(data []byte) (err error) {
 	return
 }
 
+// UserProfile 用户公开资料
+type UserProfile struct {
+	UserID      uint64    `json:"user_id,string" db:"user_id"`
+	UserName    string    `json:"username" db:"username"`
+	DisplayName string    `json:"display_name" db:"display_name"` // 昵称
+	Bio         string    `json:"bio" db:"bio"`                   // 个人简介
+	AvatarURL   string    `json:"avatar_url" db:"avatar_url"`     // 头像地址
+	Gender      int       `json:"gender" db:"gender"`             // 性别 0:未知 1:男 2:女
+	CreateTime  time.Time `json:"create_time" db:"create_time"`   // 注册时间
+}
+
+// ApiUserProfile 用户主页返回的信息结构体
+type ApiUserProfile struct {
+	*UserProfile
+	PostCount    int64 `json:"post_count"`    // 发帖数
+	CommentCount int64 `json:"comment_count"` // 评论数
+	Karma        int64 `json:"karma"`         // 帖子收到的赞成票减反对票(不含作者自己的投票)
+}
+
+// ApiMyProfile 当前用户的资料，包含不公开的邮箱信息
+type ApiMyProfile struct {
+	UserProfile
+	Email         string `json:"email" db:"email"`
+	EmailVerified bool   `json:"email_verified" db:"email_verified"`
+}
+
+// ParamUpdateProfile 定义修改个人资料时的请求参数
+type ParamUpdateProfile struct {
+	Email       string `json:"email" binding:"omitempty,email,max=64"` // 为空时不修改邮箱
+	Gender      int    `json:"gender" binding:"oneof=0 1 2"`           // 性别 0:未知 1:男 2:女
+	DisplayName string `json:"display_name" binding:"max=32"`
+	Bio         string `json:"bio" binding:"max=256"`
+	AvatarURL   string `json:"avatar_url" binding:"omitempty,url,max=256"`
+}
+
 // RegisterForm 定义用户注册时的请求参数，通过标签实现参数验证
 type RegisterForm struct {
 	UserName        string `json:"username" binding:"required"`
@@ -49,11 +89,95 @@ type RegisterForm struct {
 	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=Password"`
 }
 
-// RegisterEmailData 定义注册时发送邮箱结构体
+// 用户角色，权限依次递增
+const (
+	RoleUser      = "user"      // 普通用户
+	RoleModerator = "moderator" // 版主，只能管理被指派的社区
+	RoleAdmin     = "admin"     // 管理员
+)
+
+// roleLevels 角色的权限等级
+var roleLevels = map[string]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+// RoleAtLeast 判断角色role的权限是否不低于required
+func RoleAtLeast(role, required string) bool {
+	level, ok := roleLevels[role]
+	return ok && level >= roleLevels[required]
+}
+
+// ParamSetRole 定义设置用户角色时的请求参数
+type ParamSetRole struct {
+	Role string `json:"role" binding:"required,oneof=user moderator admin"`
+}
+
+// UserTOTP 用户的两步验证配置
+type UserTOTP struct {
+	UserID      uint64 `db:"user_id"`
+	UserName    string `db:"username"`
+	Password    string `db:"password"`
+	Secret      string `db:"totp_secret"`       // base32编码的TOTP密钥
+	Enabled     bool   `db:"totp_enabled"`      // 是否已开启
+	LastCounter uint64 `db:"totp_last_counter"` // 最近一次使用的验证码时间步，防止重放
+}
+
+// ApiTOTPEnroll 开启两步验证第一步返回的信息，恢复码只展示这一次
+type ApiTOTPEnroll struct {
+	Secret        string   `json:"secret"`
+	URI           string   `json:"otpauth_uri"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// ParamTOTPCode 定义确认开启两步验证时的请求参数
+type ParamTOTPCode struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// ParamLogin2FA 定义登录第二步的请求参数，code可以是验证码或恢复码
+type ParamLogin2FA struct {
+	Token string `json:"two_factor_token" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
+
+// ParamDisable2FA 定义关闭两步验证时的请求参数
+type ParamDisable2FA struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// 邮件任务类型
+const (
+	EmailTypeVerify        = "verify"         // 注册欢迎及邮箱验证邮件
+	EmailTypeResetPassword = "reset_password" // 重置密码邮件
+)
+
+// RegisterEmailData 定义发送邮件任务结构体
 type RegisterEmailData struct {
+	Type     string `json:"type"` // 邮件类型，为空时按邮箱验证邮件处理
 	Email    string `json:"email" binding:"required"`
 	UserName string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Token    string `json:"token" binding:"required"` // 邮件链接中的一次性令牌(邮箱验证/重置密码)
+}
+
+// ChangePasswordForm 定义修改密码时的请求参数
+type ChangePasswordForm struct {
+	OldPassword     string `json:"old_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6,nefield=OldPassword"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=NewPassword"`
+}
+
+// ForgotPasswordForm 定义忘记密码时的请求参数
+type ForgotPasswordForm struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordForm 定义通过邮件令牌重置密码时的请求参数
+type ResetPasswordForm struct {
+	Token           string `json:"token" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=NewPassword"`
 }
 
 // LoginForm 定义用户登录时的请求参数
@@ -7,22 +7,77 @@ import (
 	"fmt"
 	"go.uber.org/zap"
 	"gopkg.in/gomail.v2" // 发送电子邮件包
+	"html"
+	"net/url"
 )
 
-// SendEmail 发送邮件
+// SendEmail 根据邮件类型发送邮件
 func SendEmail(p *models.RegisterEmailData) error {
-	zap.L().Debug("Sending email", zap.String("to", p.Email),
-		zap.String("username", p.UserName))
-
-	if p == nil || p.Email == "" || p.UserName == "" {
+	if p == nil || p.Email == "" || p.UserName == "" || p.Token == "" {
 		return fmt.Errorf("invalid email data")
 	}
+	zap.L().Debug("Sending email", zap.String("type", p.Type), zap.String("to", p.Email),
+		zap.String("username", p.UserName))
 
 	if settings.Conf == nil || settings.Conf.EmailConfig == nil {
 		return fmt.Errorf("email configuration is not initialized")
 	}
 
-	message := `
+	name := html.EscapeString(p.UserName)
+	switch p.Type {
+	case models.EmailTypeVerify, "":
+		link := tokenLink(settings.Conf.EmailConfig.VerifyURL, p.Token)
+		return send(p.Email, "Welcome to Bluebell Forum! Please verify your email",
+			fmt.Sprintf(welcomeMessage, name, link, link))
+	case models.EmailTypeResetPassword:
+		link := tokenLink(settings.Conf.EmailConfig.ResetURL, p.Token)
+		return send(p.Email, "Bluebell Forum: Reset your password",
+			fmt.Sprintf(resetPasswordMessage, name, link, link))
+	default:
+		return fmt.Errorf("unknown email type %q", p.Type)
+	}
+}
+
+// send 通过SMTP发送HTML邮件
+func send(to, subject, body string) error {
+	host := "smtp.qq.com" // SMTP服务器
+	port := 465           // 使用SSL协议端口
+	username := settings.Conf.EmailConfig.UserName
+	password := settings.Conf.EmailConfig.Password
+
+	if username == "" || password == "" {
+		return fmt.Errorf("email configuration is missing")
+	}
+
+	m := gomail.NewMessage() // 创建Message实例
+	// 设置发件人、收件人、邮件主题、邮件内容
+	m.SetHeader("From", username)
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/html", body)
+
+	d := gomail.NewDialer(
+		host,
+		port,
+		username,
+		password,
+	)
+	// 跳过证书验证
+	d.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+
+	if err := d.DialAndSend(m); err != nil {
+		return err
+	}
+	return nil
+}
+
+// tokenLink 拼接邮件中的令牌链接
+func tokenLink(base, token string) string {
+	return base + "?token=" + url.QueryEscape(token)
+}
+
+// welcomeMessage 注册欢迎及邮箱验证邮件
+const welcomeMessage = `
 <!DOCTYPE html>
 <html lang="zh">
 <head>
@@ -39,6 +94,10 @@ func SendEmail(p *models.RegisterEmailData) error {
     <h1>%s，欢迎加入Bluebell 编程博客论坛！</h1>
     
     <p>感谢您加入我们的社区，在这里，充满热情的人们聚集在一起，讨论和分享各种话题。</p>
+
+    <p>请点击下面的链接验证您的邮箱，验证后即可发帖、评论和投票，链接24小时内有效：<br>
+        <a href="%s">%s</a>
+    </p>
     
     <p>我们很高兴您成为我们不断增长的社区的一部分。现在，您可以参与讨论，开新帖子，并与其他成员分享您的见解。</p>
 
@@ -59,36 +118,32 @@ func SendEmail(p *models.RegisterEmailData) error {
 </html>
 `
 
-	host := "smtp.qq.com" // SMTP服务器
-	port := 465           // 使用SSL协议端口
-	username := settings.Conf.EmailConfig.UserName
-	password := settings.Conf.EmailConfig.Password
-
-	if username == "" || password == "" {
-		return fmt.Errorf("email configuration is missing")
-	}
+// resetPasswordMessage 重置密码邮件
+const resetPasswordMessage = `
+<!DOCTYPE html>
+<html lang="zh">
+<head>
+    <meta charset="UTF-8">
+    <style>
+        p {
+            margin: 1em 0;
+            text-indent: 2em;
+            font-family: Arial, 'Microsoft YaHei', sans-serif;
+        }
+    </style>
+</head>
+<body>
+    <h1>%s，您好！</h1>
 
-	zap.L().Debug("Sending email", zap.String("to", p.Email),
-		zap.String("username", p.UserName))
+    <p>我们收到了重置您Bluebell账号密码的请求，请点击下面的链接设置新密码，链接30分钟内有效且只能使用一次：<br>
+        <a href="%s">%s</a>
+    </p>
 
-	m := gomail.NewMessage() // 创建Message实例
-	// 设置发件人、收件人、邮件主题、邮件内容
-	m.SetHeader("From", username)
-	m.SetHeader("To", p.Email)
-	m.SetHeader("Subject", "Welcome to Bluebell Forum!")
-	m.SetBody("text/html", fmt.Sprintf(message, p.UserName))
+    <p>密码重置后，您所有已登录的设备都需要使用新密码重新登录。</p>
 
-	d := gomail.NewDialer(
-		host,
-		port,
-		username,
-		password,
-	)
-	// 跳过证书验证
-	d.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+    <p>如果这不是您本人的操作，请忽略这封邮件，您的密码不会被修改。</p>
 
-	if err := d.DialAndSend(m); err != nil {
-		return err
-	}
-	return nil
-}
+    <p>祝好，<br> Bluebell编程博客论坛团队</p>
+</body>
+</html>
+`
@@ -1,6 +1,8 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -14,48 +16,96 @@ import (
 type MyClaims struct {
 	UserID             uint64 `json:"user_id"`
 	Username           string `json:"username"`
+	Role               string `json:"role"`       // 用户角色 user / moderator / admin
+	Family             string `json:"fid"`        // 所属的Token家族，一次登录签发的所有Token属于同一家族
+	TokenType          string `json:"token_type"` // Token类型，防止不同用途的Token混用
 	jwt.StandardClaims        // JWT规定的7个官方字段
 }
 
-// 定义Secret 用于加密的字符串
-var mySecret = []byte("bluebell-plus")
-
-// 获取密钥
-func keyFunc(_ *jwt.Token) (i interface{}, err error) {
-	return mySecret, nil
+// RefreshClaims refresh_token的负载，与用户及Token家族绑定，jti用于检测重放
+type RefreshClaims struct {
+	UserID    uint64 `json:"user_id"`
+	Family    string `json:"fid"`
+	TokenType string `json:"token_type"`
+	jwt.StandardClaims
 }
 
-// TokenExpireDuration 定义JWT的过期时间
-const TokenExpireDuration = time.Hour * 24
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+var ErrInvalidTokenType = errors.New("invalid token type")
+
+// rtoken解决atoken过期快的问题，有效期可在配置文件中修改
+var (
+	AccessTokenExpireDuration  = time.Hour * 24     // access_token 过期时间
+	RefreshTokenExpireDuration = time.Hour * 24 * 7 // refresh_token 过期时间
+)
+
+// newTokenID 生成随机的Token ID(jti)，用于在Redis中登记和吊销Token
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
 
-// rtoken解决atoken过期快的问题
-const AccessTokenExpireDuration = time.Hour * 24      // access_token 过期时间
-const RefreshTokenExpireDuration = time.Hour * 24 * 7 // refresh_token 过期时间
+// NewFamilyID 登录时生成新的Token家族ID
+func NewFamilyID() (string, error) {
+	return newTokenID()
+}
 
-// GenToken 生成JWT：生成access_token 和 refresh_token
-func GenToken(userID uint64, username string) (aToken, rToken string, err error) {
+// GenToken 生成JWT：生成属于family家族的access_token 和 refresh_token
+func GenToken(userID uint64, username, role, family string) (aToken, rToken string, err error) {
+	aID, err := newTokenID()
+	if err != nil {
+		return
+	}
+	rID, err := newTokenID()
+	if err != nil {
+		return
+	}
+	now := time.Now()
 	// 创建声明实例 Token负载
 	c := MyClaims{
-		userID,
-		username,
-		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(AccessTokenExpireDuration).Unix(), // 过期时间
-			Issuer:    "bluebell",                                       // 签发人
+		UserID:    userID,
+		Username:  username,
+		Role:      role,
+		Family:    family,
+		TokenType: TokenTypeAccess,
+		StandardClaims: jwt.StandardClaims{
+			Id:        aID,                                       // Token ID
+			IssuedAt:  now.Unix(),                                // 签发时间
+			ExpiresAt: now.Add(AccessTokenExpireDuration).Unix(), // 过期时间
+			Issuer:    issuer,                                    // 签发人
+			Audience:  audience,                                  // 受众
 		},
 	}
 	// access_token 加密并获得完整的编码后的字符串token：加密算法+Token负载+密钥
-	aToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString(mySecret)
+	aToken, err = sign(c)
+	if err != nil {
+		return
+	}
 
-	// refresh_token 无需存储数据，只为了刷新access_token
-	rToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{
-		ExpiresAt: time.Now().Add(RefreshTokenExpireDuration).Unix(), // 过期时间
-		Issuer:    "bluebell",                                        // 签发人
-	}).SignedString(mySecret)
-	// 使用指定的secret签名并获得完整的编码后的字符串token
+	// refresh_token 只用于刷新，携带用户ID和家族ID，每次使用后轮换
+	rToken, err = sign(RefreshClaims{
+		UserID:    userID,
+		Family:    family,
+		TokenType: TokenTypeRefresh,
+		StandardClaims: jwt.StandardClaims{
+			Id:        rID,                                        // Token ID
+			IssuedAt:  now.Unix(),                                 // 签发时间
+			ExpiresAt: now.Add(RefreshTokenExpireDuration).Unix(), // 过期时间
+			Issuer:    issuer,                                     // 签发人
+			Audience:  audience,                                   // 受众
+		},
+	})
 	return
 }
 
-// ParseToken 解析并验证JWT
+// ParseToken 解析并验证access_token
 func ParseToken(tokenString string) (claims *MyClaims, err error) {
 	// 解析TokenString
 	var token *jwt.Token
@@ -66,29 +116,32 @@ func ParseToken(tokenString string) (claims *MyClaims, err error) {
 	}
 	if !token.Valid { // 校验token
 		err = errors.New("invalid token")
+		return
 	}
+	if claims.TokenType != TokenTypeAccess {
+		err = ErrInvalidTokenType
+		return
+	}
+	err = verifyStandardClaims(&claims.StandardClaims)
 	return
 }
 
-// RefreshToken 刷新access_token
-func RefreshToken(aToken, rToken string) (newAToken, newRToken string, err error) {
-	// 若refresh token也无效则直接返回
-	if _, err = jwt.Parse(rToken, keyFunc); err != nil {
+// ParseRefreshToken 解析并验证refresh_token
+func ParseRefreshToken(tokenString string) (claims *RefreshClaims, err error) {
+	var token *jwt.Token
+	claims = new(RefreshClaims)
+	token, err = jwt.ParseWithClaims(tokenString, claims, keyFunc)
+	if err != nil {
 		return
 	}
-
-	// 从过期access token中解析出claims数据，即解析出payload负载信息
-	var claims MyClaims
-	_, err = jwt.ParseWithClaims(aToken, &claims, keyFunc)
-	v, _ := err.(*jwt.ValidationError)
-
-	// 当access token是过期错误并且refresh token没有过期时，就创建一个新的access token
-	if v.Errors == jwt.ValidationErrorExpired {
-		return GenToken(claims.UserID, claims.Username)
+	if !token.Valid {
+		err = errors.New("invalid token")
+		return
+	}
+	if claims.TokenType != TokenTypeRefresh || claims.UserID == 0 || claims.Family == "" {
+		err = ErrInvalidTokenType
+		return
 	}
-	/*
-		限制账号在同一时间只能在一个设备上登录
-		* 刷新 Access Token 的同时更新Redis中Token的过期时间
-	*/
+	err = verifyStandardClaims(&claims.StandardClaims)
 	return
 }
@@ -0,0 +1,228 @@
+package jwt
+
+import (
+	"bluebell_backend/settings"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+/**
+ * 签名密钥管理
+ * 支持同时配置多个密钥，通过Token头部的kid选择验证密钥，实现不停机轮换：
+ * 1.新增密钥并分发公钥(JWKS) 2.将signing_kid切换为新密钥 3.旧Token全部过期后删除旧密钥
+ **/
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+var (
+	ErrUnknownKey        = errors.New("unknown jwt signing key")
+	ErrAlgorithmMismatch = errors.New("jwt signing algorithm mismatch")
+)
+
+// signingKey 一个签名密钥，仅配置公钥的密钥只能用于验证
+type signingKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	signKey   interface{} // []byte / *rsa.PrivateKey / ed25519.PrivateKey
+	verifyKey interface{} // []byte / *rsa.PublicKey / ed25519.PublicKey
+}
+
+var (
+	keys       = map[string]*signingKey{} // kid -> 密钥
+	currentKey *signingKey                // 用于签发新Token的密钥
+	issuer     = "bluebell"
+	audience   string
+)
+
+// Init 根据配置加载签名密钥、签发人、受众及Token有效期
+func Init(cfg *settings.AuthConfig) (err error) {
+	if cfg == nil || cfg.JWTConfig == nil {
+		return errors.New("jwt config is not initialized")
+	}
+	if cfg.JwtExpire > 0 {
+		AccessTokenExpireDuration = time.Duration(cfg.JwtExpire) * time.Hour
+	}
+	if cfg.RefreshExpire > 0 {
+		RefreshTokenExpireDuration = time.Duration(cfg.RefreshExpire) * time.Hour
+	}
+	jc := cfg.JWTConfig
+	if jc.Issuer != "" {
+		issuer = jc.Issuer
+	}
+	audience = jc.Audience
+
+	loaded := make(map[string]*signingKey, len(jc.Keys))
+	for _, kc := range jc.Keys {
+		if kc.Kid == "" {
+			return errors.New("jwt key kid is required")
+		}
+		algorithm := kc.Algorithm
+		if algorithm == "" {
+			algorithm = jc.Algorithm
+		}
+		k, err := loadKey(kc, algorithm)
+		if err != nil {
+			return fmt.Errorf("load jwt key %s failed: %w", kc.Kid, err)
+		}
+		loaded[kc.Kid] = k
+	}
+	current, ok := loaded[jc.SigningKid]
+	if !ok {
+		return fmt.Errorf("%w: signing_kid %q", ErrUnknownKey, jc.SigningKid)
+	}
+	if current.signKey == nil {
+		return fmt.Errorf("jwt key %s has no private key for signing", current.kid)
+	}
+	keys, currentKey = loaded, current
+	return nil
+}
+
+// loadKey 根据算法加载密钥
+func loadKey(kc settings.JWTKey, algorithm string) (*signingKey, error) {
+	k := &signingKey{kid: kc.Kid}
+	switch algorithm {
+	case AlgorithmHS256:
+		if kc.Secret == "" {
+			return nil, errors.New("secret is required for HS256")
+		}
+		k.method = jwt.SigningMethodHS256
+		k.signKey = []byte(kc.Secret)
+		k.verifyKey = k.signKey
+	case AlgorithmRS256:
+		k.method = jwt.SigningMethodRS256
+		if kc.PrivateKeyFile != "" {
+			data, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			k.signKey, k.verifyKey = privateKey, &privateKey.PublicKey
+		} else if kc.PublicKeyFile != "" {
+			data, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			if k.verifyKey, err = jwt.ParseRSAPublicKeyFromPEM(data); err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, errors.New("private_key_file or public_key_file is required for RS256")
+		}
+	case AlgorithmEdDSA:
+		k.method = SigningMethodEdDSA
+		if kc.PrivateKeyFile != "" {
+			privateKey, err := parseEd25519PrivateKey(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			k.signKey, k.verifyKey = privateKey, privateKey.Public().(ed25519.PublicKey)
+		} else if kc.PublicKeyFile != "" {
+			publicKey, err := parseEd25519PublicKey(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			k.verifyKey = publicKey
+		} else {
+			return nil, errors.New("private_key_file or public_key_file is required for EdDSA")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+	return k, nil
+}
+
+// parseEd25519PrivateKey 读取PKCS#8格式的Ed25519私钥
+func parseEd25519PrivateKey(filename string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(filename)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an ed25519 private key")
+	}
+	return privateKey, nil
+}
+
+// parseEd25519PublicKey 读取PKIX格式的Ed25519公钥
+func parseEd25519PublicKey(filename string) (ed25519.PublicKey, error) {
+	der, err := readPEM(filename)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("not an ed25519 public key")
+	}
+	return publicKey, nil
+}
+
+func readPEM(filename string) ([]byte, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid PEM data")
+	}
+	return block.Bytes, nil
+}
+
+// keyFunc 根据Token头部的kid获取验证密钥，并校验签名算法与密钥一致，防止算法混淆攻击
+func keyFunc(token *jwt.Token) (interface{}, error) {
+	k := currentKey
+	if kid, ok := token.Header["kid"].(string); ok {
+		k = keys[kid]
+	}
+	if k == nil {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, ErrAlgorithmMismatch
+	}
+	return k.verifyKey, nil
+}
+
+// sign 使用当前密钥签名，并在头部写入kid
+func sign(claims jwt.Claims) (string, error) {
+	if currentKey == nil {
+		return "", ErrUnknownKey
+	}
+	token := jwt.NewWithClaims(currentKey.method, claims)
+	token.Header["kid"] = currentKey.kid
+	return token.SignedString(currentKey.signKey)
+}
+
+// verifyStandardClaims 校验签发人和受众
+func verifyStandardClaims(c *jwt.StandardClaims) error {
+	if !c.VerifyIssuer(issuer, true) {
+		return errors.New("invalid token issuer")
+	}
+	if audience != "" && !c.VerifyAudience(audience, true) {
+		return errors.New("invalid token audience")
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+/*
+Markdown渲染
+	* 按CommonMark + GFM(表格、删除线、自动链接、任务列表)将Markdown渲染为HTML
+	* 渲染结果经过白名单过滤，去掉脚本、事件属性、javascript:链接等，防止XSS
+	* 同时提供纯文本，用于生成帖子摘要
+*/
+
+var (
+	// md 不渲染Markdown中的原始HTML，原始HTML会被替换为注释
+	md = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+	// policy 用户内容的HTML白名单，代码块额外允许language-xxx样式用于语法高亮
+	policy = newPolicy()
+
+	// text 去掉所有标签，只保留文本
+	text = bluemonday.StrictPolicy()
+)
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+-]+$`)).OnElements("code")
+	p.AllowAttrs("type", "checked", "disabled").OnElements("input") // GFM任务列表
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+// Render 将Markdown渲染为过滤后的安全HTML
+func Render(src string) string {
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(src), &buf); err != nil {
+		// 渲染失败时按纯文本展示
+		return html.EscapeString(src)
+	}
+	return policy.Sanitize(buf.String())
+}
+
+// PlainText 将Markdown渲染后去掉所有标签，返回合并了空白的纯文本
+func PlainText(src string) string {
+	s := html.UnescapeString(text.Sanitize(Render(src)))
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,45 @@
+package password
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+)
+
+// legacySecret 旧版本使用的固定盐值
+const legacySecret = "huchao.vip"
+
+// legacyColumnSize 旧版本password字段长度，超长的哈希在写入时被截断
+const legacyColumnSize = 64
+
+// legacyMD5 旧版本的MD5哈希，仅用于校验已有账号，校验通过后需重新哈希
+type legacyMD5 struct{}
+
+// encode 与旧版本encryptPassword保持一致：hex(明文 + md5(secret))
+func (legacyMD5) encode(plain string) string {
+	h := md5.New()
+	h.Write([]byte(legacySecret))
+	return hex.EncodeToString(h.Sum([]byte(plain)))
+}
+
+func (l legacyMD5) Hash(string) (string, error) {
+	return "", errors.New("legacy md5 hash is verify-only")
+}
+
+func (l legacyMD5) Verify(encoded, plain string) (bool, error) {
+	expected := l.encode(plain)
+	if len(expected) > legacyColumnSize {
+		expected = expected[:legacyColumnSize]
+	}
+	return subtle.ConstantTimeCompare([]byte(encoded), []byte(expected)) == 1, nil
+}
+
+func (legacyMD5) Match(encoded string) bool {
+	_, err := hex.DecodeString(encoded)
+	return err == nil
+}
+
+func (legacyMD5) NeedsRehash(string) bool {
+	return true
+}
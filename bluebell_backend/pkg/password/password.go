@@ -0,0 +1,90 @@
+package password
+
+import (
+	"bluebell_backend/settings"
+	"errors"
+	"fmt"
+)
+
+/**
+ * 可插拔的密码哈希
+ * 哈希结果中编码了算法、参数和每个用户独立的盐值，校验时根据哈希格式自动选择算法
+ * 旧版MD5哈希仅用于校验，校验通过后由调用方使用当前算法重新哈希
+ **/
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+var ErrUnknownAlgorithm = errors.New("unknown password hash algorithm")
+
+// Hasher 密码哈希算法
+type Hasher interface {
+	// Hash 生成密码哈希，结果中包含盐值及参数
+	Hash(plain string) (string, error)
+	// Verify 校验明文密码与哈希是否匹配
+	Verify(encoded, plain string) (bool, error)
+	// Match 判断哈希是否由该算法生成
+	Match(encoded string) bool
+	// NeedsRehash 判断哈希的参数是否与当前配置不一致
+	NeedsRehash(encoded string) bool
+}
+
+var (
+	// current 当前用于生成新哈希的算法
+	current Hasher = NewArgon2id(DefaultArgon2Params)
+	// known 所有支持校验的算法，校验时使用哈希中编码的参数
+	known = []Hasher{NewArgon2id(DefaultArgon2Params), NewBcrypt(0)}
+	// legacy 旧版MD5哈希，只校验不生成
+	legacy Hasher = legacyMD5{}
+)
+
+// Init 根据配置选择密码哈希算法
+func Init(cfg *settings.PasswordConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	switch cfg.Algorithm {
+	case "", AlgorithmArgon2id:
+		params := DefaultArgon2Params
+		if cfg.Argon2Memory > 0 {
+			params.Memory = cfg.Argon2Memory
+		}
+		if cfg.Argon2Time > 0 {
+			params.Time = cfg.Argon2Time
+		}
+		if cfg.Argon2Threads > 0 {
+			params.Threads = cfg.Argon2Threads
+		}
+		current = NewArgon2id(params)
+	case AlgorithmBcrypt:
+		current = NewBcrypt(cfg.BcryptCost)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownAlgorithm, cfg.Algorithm)
+	}
+	return nil
+}
+
+// Hash 使用当前算法生成密码哈希
+func Hash(plain string) (string, error) {
+	return current.Hash(plain)
+}
+
+// Verify 校验密码，rehash为true表示校验通过但哈希应使用当前算法重新生成
+func Verify(encoded, plain string) (ok, rehash bool, err error) {
+	if current.Match(encoded) {
+		ok, err = current.Verify(encoded, plain)
+		return ok, ok && current.NeedsRehash(encoded), err
+	}
+	// 由其他算法生成的哈希(如切换了配置)，校验通过后迁移到当前算法
+	for _, h := range known {
+		if h.Match(encoded) {
+			ok, err = h.Verify(encoded, plain)
+			return ok, ok, err
+		}
+	}
+	// 不是任何已知格式，按旧版MD5哈希校验
+	ok, err = legacy.Verify(encoded, plain)
+	return ok, ok, err
+}
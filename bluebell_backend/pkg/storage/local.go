@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var errInvalidKey = errors.New("invalid storage key")
+
+// Local 本地文件系统存储
+type Local struct {
+	dir     string // 保存文件的根目录
+	baseURL string // 根目录对外的访问地址
+}
+
+// NewLocal 创建本地文件系统存储
+func NewLocal(dir, baseURL string) *Local {
+	return &Local{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Dir 保存文件的根目录
+func (l *Local) Dir() string {
+	return l.dir
+}
+
+// BaseURL 根目录对外的访问地址
+func (l *Local) BaseURL() string {
+	return l.baseURL
+}
+
+// path 将key转换为本地路径，拒绝跳出根目录的key
+func (l *Local) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || clean[1:] != key {
+		return "", errInvalidKey
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
+
+// Put 先写入临时文件再重命名，避免读到写了一半的文件
+func (l *Local) Put(key string, r io.Reader, size int64, contentType string) (err error) {
+	p, err := l.path(key)
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return
+	}
+	f, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(f.Name())
+		}
+	}()
+	if _, err = io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	return os.Rename(f.Name(), p)
+}
+
+func (l *Local) Delete(key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) URL(key string) string {
+	return l.baseURL + "/" + key
+}
+
+// LocalDir 当前使用本地存储时返回根目录和访问地址，用于注册静态文件路由
+func LocalDir() (dir, baseURL string, ok bool) {
+	l, ok := current.(*Local)
+	if !ok {
+		return "", "", false
+	}
+	return l.Dir(), l.BaseURL(), true
+}
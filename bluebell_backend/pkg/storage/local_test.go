@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalRejectsTraversalKeys(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "uploads")
+	l := NewLocal(dir, "/uploads/")
+	keys := []string{
+		"",
+		"../x",
+		"/abs",
+		"a/../../b",
+		"a/../b",
+		"a//b",
+		"./a",
+		"a/",
+		"..",
+	}
+	for _, key := range keys {
+		if err := l.Put(key, strings.NewReader("x"), 1, "text/plain"); err != errInvalidKey {
+			t.Errorf("Put(%q) error = %v, want errInvalidKey", key, err)
+		}
+		if err := l.Delete(key); err != errInvalidKey {
+			t.Errorf("Delete(%q) error = %v, want errInvalidKey", key, err)
+		}
+	}
+	// 根目录之外不能出现任何文件
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != "uploads" {
+			t.Errorf("unexpected file %q outside storage dir", e.Name())
+		}
+	}
+}
+
+func TestLocalPutDelete(t *testing.T) {
+	dir := t.TempDir()
+	l := NewLocal(dir, "/uploads/")
+	key := "2025/03/123.png"
+	if err := l.Put(key, strings.NewReader("hello"), 5, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "2025", "03", "123.png"))
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("read stored file = %q, %v", data, err)
+	}
+	if got := l.URL(key); got != "/uploads/"+key {
+		t.Errorf("URL = %q", got)
+	}
+	if err := l.Delete(key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2025", "03", "123.png")); !os.IsNotExist(err) {
+		t.Errorf("file still exists after Delete: %v", err)
+	}
+	// 删除不存在的文件不返回错误
+	if err := l.Delete(key); err != nil {
+		t.Errorf("Delete(missing) error = %v", err)
+	}
+}
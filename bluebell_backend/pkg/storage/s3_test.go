@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"bluebell_backend/settings"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// TestS3PutDelete 在本地MinIO等S3兼容服务上测试上传和删除，未设置S3_TEST_ENDPOINT时跳过
+//
+//	docker run -p 9000:9000 minio/minio server /data
+//	S3_TEST_ENDPOINT=127.0.0.1:9000 S3_TEST_ACCESS_KEY=minioadmin S3_TEST_SECRET_KEY=minioadmin go test ./pkg/storage
+func TestS3PutDelete(t *testing.T) {
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT not set")
+	}
+	bucket := os.Getenv("S3_TEST_BUCKET")
+	if bucket == "" {
+		bucket = "bluebell-test"
+	}
+	s, err := NewS3(&settings.S3Config{
+		Endpoint:  endpoint,
+		Region:    "us-east-1",
+		Bucket:    bucket,
+		AccessKey: os.Getenv("S3_TEST_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_TEST_SECRET_KEY"),
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	exists, err := s.client.BucketExists(ctx, bucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		if err = s.client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{Region: "us-east-1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	key := "test/" + strings.ReplaceAll(t.Name(), "/", "_") + ".txt"
+	body := "hello bluebell"
+	if err = s.Put(key, strings.NewReader(body), int64(len(body)), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	obj, err := s.client.GetObject(ctx, bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(obj)
+	_ = obj.Close()
+	if err != nil || string(data) != body {
+		t.Fatalf("GetObject = %q, %v, want %q", data, err, body)
+	}
+	info, err := s.client.StatObject(ctx, bucket, key, minio.StatObjectOptions{})
+	if err != nil || info.ContentType != "text/plain" {
+		t.Errorf("StatObject content type = %q, %v", info.ContentType, err)
+	}
+	if got, want := s.URL(key), "http://"+endpoint+"/"+bucket+"/"+key; got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+
+	if err = s.Delete(key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.client.StatObject(ctx, bucket, key, minio.StatObjectOptions{}); minio.ToErrorResponse(err).Code != "NoSuchKey" {
+		t.Errorf("StatObject after Delete error = %v, want NoSuchKey", err)
+	}
+	// 删除不存在的对象不返回错误
+	if err = s.Delete(key); err != nil {
+		t.Errorf("Delete(missing) error = %v", err)
+	}
+}
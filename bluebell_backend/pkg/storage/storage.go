@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"bluebell_backend/settings"
+	"errors"
+	"fmt"
+	"io"
+)
+
+/**
+ * 可插拔的文件存储
+ * 上传的图片和附件按key保存，key中不包含存储位置，切换存储时数据库中的记录不受影响
+ * local：保存到本地目录，由gin提供静态文件访问
+ * s3：保存到S3兼容的对象存储(AWS S3、MinIO等)
+ **/
+
+const (
+	TypeLocal = "local"
+	TypeS3    = "s3"
+)
+
+var ErrUnknownType = errors.New("unknown storage type")
+
+// Storage 文件存储
+type Storage interface {
+	// Put 保存文件，key已存在时覆盖
+	Put(key string, r io.Reader, size int64, contentType string) error
+	// Delete 删除文件，文件不存在时不返回错误
+	Delete(key string) error
+	// URL 文件的访问地址
+	URL(key string) string
+}
+
+// current 当前使用的存储，默认保存到本地uploads目录
+var current Storage = NewLocal("./uploads", "/uploads")
+
+// Init 根据配置选择文件存储
+func Init(cfg *settings.StorageConfig) (err error) {
+	if cfg == nil {
+		return nil
+	}
+	switch cfg.Type {
+	case "", TypeLocal:
+		if cfg.LocalConfig != nil {
+			current = NewLocal(cfg.LocalConfig.Dir, cfg.LocalConfig.BaseURL)
+		}
+	case TypeS3:
+		if cfg.S3Config == nil {
+			return fmt.Errorf("storage type s3 requires s3 config")
+		}
+		current, err = NewS3(cfg.S3Config)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownType, cfg.Type)
+	}
+	return
+}
+
+// Put 使用当前存储保存文件
+func Put(key string, r io.Reader, size int64, contentType string) error {
+	return current.Put(key, r, size, contentType)
+}
+
+// Delete 使用当前存储删除文件
+func Delete(key string) error {
+	return current.Delete(key)
+}
+
+// URL 文件在当前存储中的访问地址
+func URL(key string) string {
+	return current.URL(key)
+}
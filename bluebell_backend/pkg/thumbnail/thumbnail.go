@@ -0,0 +1,85 @@
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif" // 注册gif解码器
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // 注册webp解码器
+)
+
+// maxPixels 允许解码的最大像素数，防止解压炸弹耗尽内存
+const maxPixels = 40 * 1000 * 1000
+
+var ErrImageTooLarge = errors.New("image dimensions too large")
+
+// Thumbnail 生成的缩略图
+type Thumbnail struct {
+	Data        []byte
+	ContentType string
+	Width       int // 原图宽度
+	Height      int // 原图高度
+}
+
+// Generate 生成长边不超过maxSize的缩略图，原图更小时保持原尺寸
+// png和gif输出为png以保留透明度，其他格式输出为jpeg
+func Generate(data []byte, maxSize int) (*Thumbnail, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrImageTooLarge
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := scale(cfg.Width, cfg.Height, maxSize)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+
+	t := &Thumbnail{Width: cfg.Width, Height: cfg.Height}
+	var buf bytes.Buffer
+	if format == "png" || format == "gif" {
+		t.ContentType = "image/png"
+		err = png.Encode(&buf, dst)
+	} else {
+		t.ContentType = "image/jpeg"
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.Data = buf.Bytes()
+	return t, nil
+}
+
+// scale 按比例计算缩略图尺寸
+func scale(w, h, maxSize int) (int, int) {
+	if w <= maxSize && h <= maxSize {
+		return w, h
+	}
+	if w >= h {
+		return maxSize, atLeastOne(h * maxSize / w)
+	}
+	return atLeastOne(w * maxSize / h), maxSize
+}
+
+func atLeastOne(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// Reader 便于以io.Reader的形式保存缩略图
+func (t *Thumbnail) Reader() io.Reader {
+	return bytes.NewReader(t.Data)
+}
@@ -0,0 +1,68 @@
+package thumbnail
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// encodePNG 生成w*h的png图片
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withDimensions 修改png文件头(IHDR)中的宽高并重新计算校验和，像素数据保持不变
+func withDimensions(data []byte, w, h uint32) []byte {
+	out := append([]byte(nil), data...)
+	// 8字节文件签名 + 4字节长度 + "IHDR" 之后是宽和高
+	ihdr := out[12 : 12+4+13]
+	binary.BigEndian.PutUint32(ihdr[4:8], w)
+	binary.BigEndian.PutUint32(ihdr[8:12], h)
+	binary.BigEndian.PutUint32(out[12+4+13:], crc32.ChecksumIEEE(ihdr))
+	return out
+}
+
+func TestGenerateScalesDown(t *testing.T) {
+	th, err := Generate(encodePNG(t, 640, 320), 320)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if th.Width != 640 || th.Height != 320 || th.ContentType != "image/png" {
+		t.Fatalf("got %dx%d %s, want 640x320 image/png", th.Width, th.Height, th.ContentType)
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(th.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != 320 || cfg.Height != 160 {
+		t.Errorf("thumbnail is %dx%d, want 320x160", cfg.Width, cfg.Height)
+	}
+}
+
+func TestGenerateRejectsPixelBomb(t *testing.T) {
+	// 文件只有几十字节，但声明的尺寸解码后需要数GB内存
+	bomb := withDimensions(encodePNG(t, 1, 1), 50000, 50000)
+	if cfg, err := png.DecodeConfig(bytes.NewReader(bomb)); err != nil || cfg.Width != 50000 {
+		t.Fatalf("crafted header not decodable: %v", err)
+	}
+	if _, err := Generate(bomb, 320); err != ErrImageTooLarge {
+		t.Fatalf("Generate(pixel bomb) error = %v, want ErrImageTooLarge", err)
+	}
+	// 刚好超过上限的尺寸同样拒绝
+	if _, err := Generate(withDimensions(encodePNG(t, 1, 1), maxPixels/1000+1, 1000), 320); err != ErrImageTooLarge {
+		t.Fatalf("Generate(just over limit) error = %v, want ErrImageTooLarge", err)
+	}
+}
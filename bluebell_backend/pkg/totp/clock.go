@@ -0,0 +1,50 @@
+package totp
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock 时间来源，验证码依赖当前时间，测试时可以替换为FakeClock
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// SystemClock 系统时钟
+var SystemClock Clock = systemClock{}
+
+// FakeClock 手动控制的时钟，用于离线测试
+type FakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+// NewFakeClock 创建停在t时刻的时钟
+func NewFakeClock(t time.Time) *FakeClock {
+	return &FakeClock{t: t}
+}
+
+// Now 返回时钟当前时间
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+// Set 将时钟设置为t时刻
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	c.t = t
+	c.mu.Unlock()
+}
+
+// Advance 将时钟向前拨动d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.t = c.t.Add(d)
+	c.mu.Unlock()
+}
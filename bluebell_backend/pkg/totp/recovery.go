@@ -0,0 +1,54 @@
+package totp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+)
+
+// UsageStore 记录已使用的验证码时间步和恢复码，测试时可以替换为内存实现
+// 两个方法都必须是原子的条件更新：时间步不大于上次记录的时间步、恢复码不存在或已使用时返回false
+type UsageStore interface {
+	UseCounter(userID, counter uint64) (bool, error)
+	UseRecoveryCode(userID uint64, codeHash string) (bool, error)
+}
+
+// Verify 校验验证码或恢复码，位数与验证码相同时按验证码校验，否则按恢复码校验
+// 验证码和恢复码都只能使用一次
+func (t *TOTP) Verify(store UsageStore, userID uint64, secret, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == t.Digits {
+		counter, ok, err := t.Validate(secret, code)
+		if err != nil || !ok {
+			return false, err
+		}
+		return store.UseCounter(userID, counter)
+	}
+	return store.UseRecoveryCode(userID, HashRecoveryCode(code))
+}
+
+// GenerateRecoveryCodes 生成n个恢复码，返回明文(展示给用户)和摘要(保存到数据库)
+func GenerateRecoveryCodes(n int) (codes, hashes []string, err error) {
+	codes = make([]string, 0, n)
+	hashes = make([]string, 0, n)
+	b := make([]byte, 8)
+	for i := 0; i < n; i++ {
+		if _, err = rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		s := strings.ToLower(base32.StdEncoding.EncodeToString(b))[:10]
+		code := s[:5] + "-" + s[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	return
+}
+
+// HashRecoveryCode 恢复码的摘要，忽略大小写和分隔符
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,166 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret RFC 6238 附录B中SHA1使用的密钥
+var rfcSecret = b32.EncodeToString([]byte("12345678901234567890"))
+
+// memoryStore 内存中的UsageStore，与MySQL实现相同的条件更新语义
+type memoryStore struct {
+	last  map[uint64]uint64
+	codes map[string]bool // 恢复码摘要 -> 是否已使用
+}
+
+func newMemoryStore(hashes ...string) *memoryStore {
+	s := &memoryStore{last: map[uint64]uint64{}, codes: map[string]bool{}}
+	for _, h := range hashes {
+		s.codes[h] = false
+	}
+	return s
+}
+
+func (s *memoryStore) UseCounter(userID, counter uint64) (bool, error) {
+	if counter <= s.last[userID] {
+		return false, nil
+	}
+	s.last[userID] = counter
+	return true, nil
+}
+
+func (s *memoryStore) UseRecoveryCode(userID uint64, codeHash string) (bool, error) {
+	used, ok := s.codes[codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	s.codes[codeHash] = true
+	return true, nil
+}
+
+func TestRFC6238Vectors(t *testing.T) {
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, v := range vectors {
+		clock := NewFakeClock(time.Unix(v.unix, 0))
+		tp := New("test", clock)
+		tp.Digits = 8
+		code, err := tp.Code(rfcSecret, clock.Now())
+		if err != nil {
+			t.Fatalf("Code(%d): %v", v.unix, err)
+		}
+		if code != v.code {
+			t.Errorf("Code(%d) = %s, want %s", v.unix, code, v.code)
+		}
+		if _, ok, err := tp.Validate(rfcSecret, v.code); err != nil || !ok {
+			t.Errorf("Validate(%d) = %v, %v, want ok", v.unix, ok, err)
+		}
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	clock := NewFakeClock(now)
+	tp := New("test", clock)
+	cases := []struct {
+		offset time.Duration
+		ok     bool
+	}{
+		{0, true},
+		{-DefaultPeriod, true},
+		{DefaultPeriod, true},
+		{-2 * DefaultPeriod, false},
+		{2 * DefaultPeriod, false},
+	}
+	for _, c := range cases {
+		code, err := tp.Code(rfcSecret, now.Add(c.offset))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok, err := tp.Validate(rfcSecret, code); err != nil || ok != c.ok {
+			t.Errorf("code generated at offset %v: ok = %v, err = %v, want ok = %v", c.offset, ok, err, c.ok)
+		}
+	}
+
+	// 时钟向前拨动超出偏差范围后，原来的验证码失效
+	code, _ := tp.Code(rfcSecret, now)
+	clock.Advance(2 * DefaultPeriod)
+	if _, ok, _ := tp.Validate(rfcSecret, code); ok {
+		t.Error("code accepted after clock advanced beyond skew window")
+	}
+}
+
+func TestVerifyRejectsReplay(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	clock := NewFakeClock(now)
+	tp := New("test", clock)
+	store := newMemoryStore()
+
+	code, _ := tp.Code(rfcSecret, now)
+	if ok, err := tp.Verify(store, 1, rfcSecret, code); err != nil || !ok {
+		t.Fatalf("first Verify = %v, %v, want ok", ok, err)
+	}
+	if ok, _ := tp.Verify(store, 1, rfcSecret, code); ok {
+		t.Error("same code accepted twice")
+	}
+	// 仍在偏差范围内但早于已使用时间步的验证码同样拒绝
+	previous, _ := tp.Code(rfcSecret, now.Add(-DefaultPeriod))
+	if ok, _ := tp.Verify(store, 1, rfcSecret, previous); ok {
+		t.Error("code of an earlier time step accepted after a later one was used")
+	}
+	// 其他用户的记录互不影响
+	if ok, _ := tp.Verify(store, 2, rfcSecret, code); !ok {
+		t.Error("code rejected for another user")
+	}
+
+	clock.Advance(DefaultPeriod)
+	next, _ := tp.Code(rfcSecret, clock.Now())
+	if ok, err := tp.Verify(store, 1, rfcSecret, next); err != nil || !ok {
+		t.Errorf("code of the next time step = %v, %v, want ok", ok, err)
+	}
+}
+
+func TestRecoveryCodesAreSingleUse(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 10 || len(hashes) != 10 {
+		t.Fatalf("got %d codes and %d hashes, want 10", len(codes), len(hashes))
+	}
+	seen := map[string]bool{}
+	for _, h := range hashes {
+		if seen[h] {
+			t.Fatal("duplicate recovery code")
+		}
+		seen[h] = true
+	}
+
+	tp := New("test", NewFakeClock(time.Unix(1234567890, 0)))
+	store := newMemoryStore(hashes...)
+	// 恢复码忽略大小写和分隔符
+	input := strings.ToUpper(strings.ReplaceAll(codes[0], "-", " "))
+	if ok, err := tp.Verify(store, 1, rfcSecret, input); err != nil || !ok {
+		t.Fatalf("Verify(recovery code) = %v, %v, want ok", ok, err)
+	}
+	if ok, _ := tp.Verify(store, 1, rfcSecret, codes[0]); ok {
+		t.Error("recovery code accepted twice")
+	}
+	if ok, _ := tp.Verify(store, 1, rfcSecret, codes[1]); !ok {
+		t.Error("unused recovery code rejected")
+	}
+	if ok, _ := tp.Verify(store, 1, rfcSecret, "aaaaa-bbbbb"); ok {
+		t.Error("unknown recovery code accepted")
+	}
+}
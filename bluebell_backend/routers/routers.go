@@ -5,6 +5,8 @@ import (
 	_ "bluebell_backend/docs" // 千万不要忘了导入把你上一步生成的docs
 	"bluebell_backend/logger"
 	"bluebell_backend/middlewares"
+	"bluebell_backend/models"
+	"bluebell_backend/pkg/storage"
 	"net/http"
 	"time"
 
@@ -13,15 +15,21 @@ import (
 	"github.com/swaggo/gin-swagger/swaggerFiles"
 
 	"github.com/gin-contrib/pprof"
+	"go.uber.org/zap"
 )
 
 // SetupRouter 设置路由
-func SetupRouter(mode string) *gin.Engine {
+func SetupRouter(mode string, trustedProxies []string) *gin.Engine {
 	if mode == gin.ReleaseMode {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	// 创建无任何middle路由
 	r := gin.New()
+	// 只信任配置的反向代理，否则任何客户端都可以通过X-Forwarded-For伪造IP
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		zap.L().Error("invalid trusted_proxies, use remote address as client ip", zap.Error(err))
+		_ = r.SetTrustedProxies(nil)
+	}
 
 	// 设置中间件
 	r.Use(logger.GinLogger(),
@@ -32,17 +40,39 @@ func SetupRouter(mode string) *gin.Engine {
 	// 注册swagger
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// 本地存储的上传文件
+	if dir, baseURL, ok := storage.LocalDir(); ok {
+		r.Static(baseURL, dir)
+	}
+
+	// 签名公钥，供其他服务验证Token
+	r.GET("/.well-known/jwks.json", controller.JWKSHandler)
+
 	v1 := r.Group("/api/v1") // 创建API v1版本路由组
 	// 登录注册业务
 	v1.POST("/login", controller.LoginHandler)
+	v1.POST("/login/2fa", controller.Login2FAHandler) // 两步验证登录
 	v1.POST("/signup", controller.SignUpHandler)
-	v1.GET("/refresh_token", controller.RefreshTokenHandler) // 刷新accessToken
+	v1.GET("/refresh_token", controller.RefreshTokenHandler)      // 刷新accessToken
+	v1.GET("/verify_email", controller.VerifyEmailHandler)        // 验证邮箱
+	v1.POST("/password/forgot", controller.ForgotPasswordHandler) // 忘记密码，发送重置邮件
+	v1.POST("/password/reset", controller.ResetPasswordHandler)   // 通过邮件令牌重置密码
 
 	// 帖子业务
-	v1.GET("/post/:id", controller.PostDetailHandler) // 根据帖子id查询帖子详情
-	v1.GET("/posts", controller.PostListHandler)      // 分页展示帖子列表
-	v1.GET("/posts2", controller.PostList2Handler)    // 根据发布时间或者分数排序分页展示(所有/某社区)帖子列表
-	v1.GET("/search", controller.PostSearchHandler)   // 搜索业务-搜索帖子
+	v1.GET("/post/:id", middlewares.OptionalJWTAuthMiddleware(), controller.PostDetailHandler) // 根据帖子id查询帖子详情，登录时返回是否已收藏
+	v1.GET("/posts", controller.PostListHandler)                                               // 分页展示帖子列表
+	v1.GET("/posts2", controller.PostList2Handler)                                             // 根据发布时间或者分数排序分页展示(所有/某社区)帖子列表
+	v1.GET("/search", controller.PostSearchHandler)                                            // 搜索业务-搜索帖子
+
+	v1.GET("/post/:id/comments", middlewares.OptionalJWTAuthMiddleware(), controller.CommentTreeHandler) // 帖子的评论树，登录时返回当前用户的投票
+	v1.GET("/post/:id/revisions", controller.PostRevisionsHandler)                                       // 帖子版本列表
+	v1.GET("/post/:id/revisions/diff", controller.PostRevisionDiffHandler)                               // 比较帖子的两个版本
+
+	v1.GET("/tag/:name", controller.TagDetailHandler)      // 标签页：标签信息及该标签下的帖子列表
+	v1.GET("/tags/popular", controller.PopularTagsHandler) // 热门标签
+
+	// 用户主页
+	v1.GET("/user/:id", controller.UserProfileHandler)
 
 	// 社区业务
 	v1.GET("/community", controller.CommunityHandler)           // 获取分类社区列表
@@ -51,18 +81,71 @@ func SetupRouter(mode string) *gin.Engine {
 	// JWT认证中间件
 	v1.Use(middlewares.JWTAuthMiddleware())
 	{
-		v1.POST("/post", controller.CreatePostHandler) // 创建帖子
+		v1.POST("/logout", controller.LogoutHandler)                         // 注销当前设备
+		v1.POST("/logout/all", controller.LogoutAllHandler)                  // 注销所有设备
+		v1.POST("/verify_email/resend", controller.ResendVerifyEmailHandler) // 重新发送验证邮件
+		v1.POST("/password/change", controller.ChangePasswordHandler)        // 修改密码
+		v1.GET("/me", controller.MyProfileHandler)                           // 查询个人资料
+		v1.PUT("/me", controller.UpdateMyProfileHandler)                     // 修改个人资料
+		v1.POST("/2fa/enroll", controller.EnrollTOTPHandler)                 // 生成两步验证密钥和恢复码
+		v1.POST("/2fa/enable", controller.EnableTOTPHandler)                 // 确认开启两步验证
+		v1.POST("/2fa/disable", controller.DisableTOTPHandler)               // 关闭两步验证
+
+		// 邮箱验证后才能发帖、投票、评论(由配置require_verified_email控制)
+		verified := middlewares.VerifiedEmailMiddleware()
+
+		v1.POST("/post", verified, controller.CreatePostHandler)                           // 创建帖子
+		v1.PUT("/post/:id", controller.UpdatePostHandler)                                  // 编辑帖子
+		v1.DELETE("/post/:id", controller.DeletePostHandler)                               // 删除帖子
+		v1.POST("/post/:id/revisions/:rev/restore", controller.RestorePostRevisionHandler) // 恢复帖子版本
+		v1.POST("/post/:id/publish", controller.PublishPostHandler)                        // 发布草稿
+		v1.POST("/upload", verified, controller.UploadHandler)                             // 上传图片或附件
+		v1.GET("/drafts", controller.DraftListHandler)                                     // 我的草稿和定时发布的帖子
 
-		v1.POST("/vote", controller.VoteHandler) // 投票
+		v1.POST("/vote", verified, controller.VoteHandler)                   // 投票
+		v1.POST("/post/:id/poll/vote", verified, controller.VotePollHandler) // 参与帖子的投票
+		v1.POST("/post/:id/poll/close", controller.ClosePollHandler)         // 提前截止帖子的投票
 
-		v1.POST("/comment", controller.CommentHandler)    // 评论
-		v1.GET("/comment", controller.CommentListHandler) // 评论列表
+		v1.POST("/comment", verified, controller.CommentHandler)              // 评论
+		v1.GET("/comment", controller.CommentListHandler)                     // 评论列表
+		v1.PUT("/comment/:id", controller.UpdateCommentHandler)               // 编辑评论
+		v1.DELETE("/comment/:id", controller.DeleteCommentHandler)            // 删除评论
+		v1.POST("/comment/:id/vote", verified, controller.CommentVoteHandler) // 为评论投票
+
+		v1.POST("/post/:id/bookmark", controller.AddBookmarkHandler)         // 收藏帖子
+		v1.DELETE("/post/:id/bookmark", controller.RemoveBookmarkHandler)    // 取消收藏
+		v1.GET("/me/bookmarks", controller.BookmarkListHandler)              // 我收藏的帖子
+		v1.GET("/me/collections", controller.CollectionListHandler)          // 我的收藏夹
+		v1.POST("/me/collections", controller.CreateCollectionHandler)       // 创建收藏夹
+		v1.DELETE("/me/collections/:id", controller.DeleteCollectionHandler) // 删除收藏夹
+
+		v1.GET("/notifications", controller.NotificationListHandler)                   // 我的通知
+		v1.POST("/notifications/read_all", controller.MarkAllNotificationsReadHandler) // 全部标为已读
+		v1.POST("/notifications/:id/read", controller.MarkNotificationReadHandler)     // 标为已读
 
 		v1.GET("/ping", func(c *gin.Context) {
 			c.String(http.StatusOK, "ping success")
 		})
 	}
 
+	// 版主业务：管理被指派的社区，管理员可以管理所有社区
+	moderator := v1.Group("/community/:id", middlewares.RequireCommunityModerator("id"))
+	{
+		moderator.DELETE("/post/:post_id", controller.ModeratorRemovePostHandler)          // 删除帖子
+		moderator.PUT("/post/:post_id/flags", controller.SetPostFlagsHandler)              // 置顶、锁定、设为精华
+		moderator.DELETE("/comment/:comment_id", controller.ModeratorRemoveCommentHandler) // 删除评论
+	}
+
+	// 管理员业务
+	admin := v1.Group("/admin", middlewares.RequireRole(models.RoleAdmin))
+	{
+		admin.POST("/user/:id/logout", controller.ForceLogoutHandler)                         // 强制注销用户的所有设备
+		admin.PUT("/user/:id/role", controller.SetUserRoleHandler)                            // 设置用户角色
+		admin.POST("/community", controller.CreateCommunityHandler)                           // 创建社区
+		admin.POST("/community/:id/moderators", controller.AddModeratorHandler)               // 指派社区版主
+		admin.DELETE("/community/:id/moderators/:user_id", controller.RemoveModeratorHandler) // 撤销社区版主
+	}
+
 	pprof.Register(r) // 注册pprof相关路由
 
 	// 处理其他路由
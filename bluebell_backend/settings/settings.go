@@ -10,16 +10,30 @@ import (
 var Conf = new(AppConfig)
 
 type AppConfig struct {
-	Mode         string `mapstructure:"mode"`
-	Port         int    `mapstructure:"port"`
-	Name         string `mapstructure:"name"`
-	Version      string `mapstructure:"version"`
-	StartTime    string `mapstructure:"start_time"`
-	MachineID    uint16 `mapstructure:"machine_id"`
-	*LogConfig   `mapstructure:"log"`
-	*MySQLConfig `mapstructure:"mysql"`
-	*RedisConfig `mapstructure:"redis"`
-	*EmailConfig `mapstructure:"email"`
+	Mode      string `mapstructure:"mode"`
+	Port      int    `mapstructure:"port"`
+	Name      string `mapstructure:"name"`
+	Version   string `mapstructure:"version"`
+	StartTime string `mapstructure:"start_time"`
+	MachineID uint16 `mapstructure:"machine_id"`
+	// 反向代理的IP或网段，只信任这些地址转发的X-Forwarded-For，为空时使用连接的IP作为客户端IP
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// 定时发布帖子的扫描间隔(秒)
+	PublishInterval int `mapstructure:"publish_interval"`
+	// 帖子浏览数同步到MySQL的间隔(秒)
+	ViewFlushInterval int `mapstructure:"view_flush_interval"`
+	// 每个独立访客的浏览为帖子增加的热度分数，0表示浏览不影响热度
+	ViewScore float64 `mapstructure:"view_score"`
+	// 评论树最多返回的层数，更深的回复折叠后按需加载
+	CommentMaxDepth int `mapstructure:"comment_max_depth"`
+	// 按MySQL校正帖子评论数的间隔(分钟)
+	CommentReconcileInterval int `mapstructure:"comment_reconcile_interval"`
+	*LogConfig               `mapstructure:"log"`
+	*MySQLConfig             `mapstructure:"mysql"`
+	*RedisConfig             `mapstructure:"redis"`
+	*EmailConfig             `mapstructure:"email"`
+	*AuthConfig              `mapstructure:"auth"`
+	*StorageConfig           `mapstructure:"storage"`
 }
 
 type MySQLConfig struct {
@@ -50,10 +64,73 @@ type LogConfig struct {
 }
 
 type EmailConfig struct {
-	SmtpHost string `mapstructure:"smtp_host"`
-	SmtpPort int    `mapstructure:"smtp_port"`
-	UserName string `mapstructure:"username"`
-	Password string `mapstructure:"password"`
+	SmtpHost  string `mapstructure:"smtp_host"`
+	SmtpPort  int    `mapstructure:"smtp_port"`
+	UserName  string `mapstructure:"username"`
+	Password  string `mapstructure:"password"`
+	VerifyURL string `mapstructure:"verify_url"` // 邮箱验证链接地址，令牌以token参数拼接在后面
+	ResetURL  string `mapstructure:"reset_url"`  // 重置密码页面地址，令牌以token参数拼接在后面
+}
+
+type StorageConfig struct {
+	Type          string   `mapstructure:"type"`           // local 或 s3
+	MaxSize       int64    `mapstructure:"max_size"`       // 单个文件大小上限(MB)
+	AllowedTypes  []string `mapstructure:"allowed_types"`  // 允许上传的文件类型(按文件内容识别的MIME类型)
+	ThumbnailSize int      `mapstructure:"thumbnail_size"` // 缩略图长边的像素数
+	OrphanTTL     int      `mapstructure:"orphan_ttl"`     // 未关联帖子的附件保留时间(小时)，之后被清理
+	GCInterval    int      `mapstructure:"gc_interval"`    // 清理未关联附件的间隔(分钟)
+	*LocalConfig  `mapstructure:"local"`
+	*S3Config     `mapstructure:"s3"`
+}
+
+type LocalConfig struct {
+	Dir     string `mapstructure:"dir"`      // 保存文件的目录
+	BaseURL string `mapstructure:"base_url"` // 文件的访问路径
+}
+
+type S3Config struct {
+	Endpoint  string `mapstructure:"endpoint"`   // 服务地址，如 s3.amazonaws.com 或本地MinIO的 127.0.0.1:9000
+	Region    string `mapstructure:"region"`     // 区域
+	Bucket    string `mapstructure:"bucket"`     // 存储桶
+	AccessKey string `mapstructure:"access_key"` // 访问密钥ID
+	SecretKey string `mapstructure:"secret_key"` // 访问密钥
+	UseSSL    bool   `mapstructure:"use_ssl"`    // 是否使用https
+	PathStyle bool   `mapstructure:"path_style"` // 使用path-style访问存储桶，MinIO需要开启
+	BaseURL   string `mapstructure:"base_url"`   // 文件的公开访问地址(如CDN)，为空时使用endpoint/bucket
+}
+
+type AuthConfig struct {
+	JwtExpire            int    `mapstructure:"jwt_expire"`             // access_token 有效期(小时)
+	RefreshExpire        int    `mapstructure:"refresh_expire"`         // refresh_token 有效期(小时)
+	MaxDevices           int    `mapstructure:"max_devices"`            // 同一账号同时登录的设备数上限
+	RequireVerifiedEmail bool   `mapstructure:"require_verified_email"` // 邮箱验证后才允许发帖、评论、投票
+	TOTPIssuer           string `mapstructure:"totp_issuer"`            // 两步验证在验证器应用中显示的签发方
+	*JWTConfig           `mapstructure:"jwt"`
+	*PasswordConfig      `mapstructure:"password"`
+}
+
+type JWTConfig struct {
+	Algorithm  string   `mapstructure:"algorithm"`   // 默认签名算法 HS256 / RS256 / EdDSA
+	Issuer     string   `mapstructure:"issuer"`      // 签发人
+	Audience   string   `mapstructure:"audience"`    // 受众
+	SigningKid string   `mapstructure:"signing_kid"` // 签发新Token使用的密钥
+	Keys       []JWTKey `mapstructure:"keys"`        // 所有有效的密钥，轮换期间新旧密钥同时存在
+}
+
+type JWTKey struct {
+	Kid            string `mapstructure:"kid"`              // 密钥ID，写入Token头部
+	Algorithm      string `mapstructure:"algorithm"`        // 签名算法，为空时使用默认算法
+	Secret         string `mapstructure:"secret"`           // HS256 密钥
+	PrivateKeyFile string `mapstructure:"private_key_file"` // RS256/EdDSA 私钥(PEM)
+	PublicKeyFile  string `mapstructure:"public_key_file"`  // 仅用于验证的旧密钥可只配置公钥(PEM)
+}
+
+type PasswordConfig struct {
+	Algorithm     string `mapstructure:"algorithm"`      // argon2id 或 bcrypt
+	BcryptCost    int    `mapstructure:"bcrypt_cost"`    // bcrypt cost
+	Argon2Memory  uint32 `mapstructure:"argon2_memory"`  // argon2id 内存开销(KiB)
+	Argon2Time    uint32 `mapstructure:"argon2_time"`    // argon2id 迭代次数
+	Argon2Threads uint8  `mapstructure:"argon2_threads"` // argon2id 并行度
 }
 
 func Init() error {